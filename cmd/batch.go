@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kojikawamura/gh-ccimg/download"
+	"github.com/kojikawamura/gh-ccimg/github"
+	"github.com/kojikawamura/gh-ccimg/httpcache"
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/output"
+	"github.com/kojikawamura/gh-ccimg/ratelimit"
+	"github.com/kojikawamura/gh-ccimg/security"
+	"github.com/kojikawamura/gh-ccimg/storage"
+	"github.com/kojikawamura/gh-ccimg/util"
+)
+
+// session holds the state shared by every target processed in one run, so a
+// batch reuses one download worker pool and one API client per host
+type session struct {
+	format   output.Format
+	batch    bool
+	filter   github.CommentFilter
+	fetcher  *download.Fetcher
+	governor *ratelimit.Governor
+	cache    *httpcache.Cache
+	names    *storage.NameTemplate // Filename template for disk mode, nil for img-NN
+	dedupe   storage.DedupeMode
+	similar  int                     // Near-duplicate threshold for --dedupe-similar, 0 when disabled
+	resize   *imaging.ResizeOptions  // Limits for --resize, nil when disabled
+	convert  imaging.Format          // Target of --convert-to, empty when disabled
+	quality  int                     // JPEG quality for --convert-to
+	frames   *imaging.FrameSelection // Frames to extract for --gif-frames, nil when disabled
+	sheets   *imaging.SheetOptions   // Layout for --contact-sheet, nil when disabled
+	strip    bool                    // Remove metadata before storing, see --strip-metadata
+	clients  map[string]*github.Client
+}
+
+// newSession creates the shared fetcher and progress reporter for a run
+func newSession(format output.Format, batch bool, filter github.CommentFilter) *session {
+	maxSizeBytes := maxSize * 1024 * 1024 // Convert MB to bytes
+	util.Debug("Download configuration - Max size: %d MB (%d bytes), Timeout: %ds, Concurrency: 5", maxSize, maxSizeBytes, timeout)
+	fetcher := download.NewFetcher(maxSizeBytes, time.Duration(timeout)*time.Second, 5)
+
+	// Set up progress reporting
+	var reporter download.Reporter = download.NewNoOpReporter()
+	if verbose || debug {
+		reporter = download.NewConsoleReporter(os.Stderr, true)
+	} else if !quiet {
+		reporter = download.NewConsoleReporter(os.Stderr, false)
+	}
+	fetcher.SetReporter(reporter)
+
+	// One governor spans API calls and downloads, so a rate limit hit by
+	// any of them pauses every request to that host
+	governor := ratelimit.New(ratelimit.DefaultMaxWait)
+	if observer, ok := reporter.(download.RateLimitReporter); ok {
+		governor.SetObserver(observer.RateLimited)
+	}
+	fetcher.SetGovernor(governor)
+
+	// Conditional requests against the on-disk cache make re-runs cheap
+	var cache *httpcache.Cache
+	if !noCache {
+		if dir, err := httpcache.DefaultDir(); err == nil {
+			util.Debug("Using HTTP cache in %s", dir)
+			cache = httpcache.New(dir)
+			fetcher.SetCache(cache)
+		} else {
+			util.Debug("HTTP cache disabled: %v", err)
+		}
+	}
+
+	return &session{
+		format:   format,
+		batch:    batch,
+		filter:   filter,
+		fetcher:  fetcher,
+		governor: governor,
+		cache:    cache,
+		dedupe:   storage.DedupeSkip,
+		clients:  make(map[string]*github.Client),
+	}
+}
+
+// client returns the API client for host, creating it on first use
+func (s *session) client(host string) *github.Client {
+	if client, ok := s.clients[host]; ok {
+		return client
+	}
+
+	util.Debug("Creating GitHub client for %s with timeout: %ds", host, timeout)
+	client := github.NewClientWithOptions(github.ClientOptions{
+		Host:    host,
+		Timeout: time.Duration(timeout) * time.Second,
+		Cache:   s.cache,
+	})
+	client.SetGovernor(s.governor)
+	s.clients[host] = client
+
+	// Private attachments need the gh auth token for the target's host; it
+	// is scoped to that host so third-party image hosts never see it
+	if token := github.TokenForHost(host); token != "" {
+		util.Debug("Using gh auth token for downloads from %s", host)
+		s.fetcher.SetAuthToken(host, token)
+		if host != github.DefaultHost {
+			// Enterprise Server with subdomain isolation serves uploads from media.HOST
+			s.fetcher.SetAuthToken("media."+host, token)
+		}
+	}
+
+	return client
+}
+
+// runBatch processes every target in turn, continuing past failures, and
+// writes one report per target
+func (s *session) runBatch(targets []*github.Target) error {
+	util.Info("Processing %d targets", len(targets))
+
+	reports := make([]*output.Report, 0, len(targets))
+	var firstErr error
+	failed, imageCount := 0, 0
+	for i, target := range targets {
+		util.Info("[%d/%d] %s", i+1, len(targets), target)
+
+		var result *targetResult
+		dir, err := batchOutputDir(outDir, target)
+		if err == nil {
+			result, err = s.processTarget(target, dir)
+		}
+
+		report := output.NewReport(target.String())
+		if result != nil {
+			report = result.report
+		}
+		if err != nil {
+			report.Error = err.Error()
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		reports = append(reports, report)
+		imageCount += len(report.Images)
+		logTargetSummary(report, dir)
+	}
+
+	if s.format != output.FormatText {
+		if err := output.WriteBatch(os.Stdout, s.format, reports); err != nil {
+			return util.NewFileSystemError("Failed to write output", err)
+		}
+	}
+
+	if failed > 0 {
+		util.Warn("Processed %d targets: %d images, %d targets failed", len(targets), imageCount, failed)
+		return batchError(failed, len(targets), firstErr)
+	}
+	util.Success("Processed %d targets: %d images", len(targets), imageCount)
+	return nil
+}
+
+// logTargetSummary prints the one-line outcome of a target in a batch
+func logTargetSummary(report *output.Report, dir string) {
+	switch {
+	case report.Error != "":
+		util.Error("%s: %s", report.Target, report.Error)
+	case dir != "":
+		util.Success("%s: %d images saved to %s, %d failed", report.Target, len(report.Images), dir, len(report.Failures))
+	default:
+		util.Success("%s: %d images, %d failed", report.Target, len(report.Images), len(report.Failures))
+	}
+}
+
+// batchError summarizes failed targets, keeping the exit code of the first failure
+func batchError(failed, total int, firstErr error) error {
+	err := util.NewAppError(util.ErrorTypeGeneric, fmt.Sprintf("%d of %d targets failed", failed, total), firstErr)
+	err.Code = util.GetExitCode(firstErr)
+	return err
+}
+
+// batchOutputDir returns the per-target subdirectory of base, or "" in memory mode
+func batchOutputDir(base string, target *github.Target) (string, error) {
+	if base == "" {
+		return "", nil
+	}
+
+	name := security.SanitizeFilename(targetDirName(target))
+	if err := security.ValidateOutputPath(base, name); err != nil {
+		return "", util.NewSecurityError(fmt.Sprintf("Invalid output directory for %s: %v", target, err))
+	}
+	return filepath.Join(base, name), nil
+}
+
+// targetDirName names a target's output directory, e.g. owner-repo-123,
+// prefixed with the host for GitHub Enterprise Server targets and suffixed
+// with the fragment for comment permalinks
+func targetDirName(target *github.Target) string {
+	parts := []string{target.Owner, target.Repo, target.Number}
+	if target.Host != "" && target.Host != github.DefaultHost {
+		parts = append([]string{target.Host}, parts...)
+	}
+	if target.IsComment() {
+		parts = append(parts, target.Fragment())
+	}
+	return strings.Join(parts, "-")
+}
+
+// collectTargets parses the command line targets plus any listed in
+// targetsFile ("-" reads stdin), expanding ranges and dropping duplicates.
+// batch reports whether the run should be treated as a batch, which is the
+// case for more than one input, a range, or a targets file.
+func collectTargets(args []string, targetsFile string, stdin io.Reader, hosts github.Hosts) (targets []*github.Target, batch bool, err error) {
+	inputs := append([]string(nil), args...)
+	if targetsFile != "" {
+		listed, err := readTargetList(targetsFile, stdin)
+		if err != nil {
+			return nil, false, util.NewFileSystemError("Failed to read targets file", err)
+		}
+		inputs = append(inputs, listed...)
+		batch = true
+	}
+
+	for _, input := range inputs {
+		expanded, err := github.ParseTargetRange(input, hosts)
+		if err != nil {
+			util.Debug("Parse error for %q: %v", input, err)
+			return nil, false, util.NewValidationError(fmt.Sprintf("Invalid target format: %s", input),
+				"Use format: OWNER/REPO#NUM, OWNER/REPO#START-END, HOST/OWNER/REPO#NUM or https://HOST/OWNER/REPO/issues/NUM for a host you are logged in to with gh")
+		}
+		targets = append(targets, expanded...)
+		if len(expanded) > 1 {
+			batch = true
+		}
+	}
+
+	if len(inputs) > 1 {
+		batch = true
+	}
+	return dedupeTargets(targets), batch, nil
+}
+
+// dedupeTargets drops repeated targets, keeping the first occurrence
+func dedupeTargets(targets []*github.Target) []*github.Target {
+	seen := make(map[string]bool)
+	unique := make([]*github.Target, 0, len(targets))
+	for _, target := range targets {
+		key := strings.ToLower(target.String())
+		if seen[key] {
+			util.Debug("Skipping duplicate target %s", target)
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, target)
+	}
+	return unique
+}
+
+// search returns the targets matching a GitHub search query on host
+func (s *session) search(host, query string, limit int) ([]*github.Target, error) {
+	if limit <= 0 {
+		return nil, util.NewValidationError(fmt.Sprintf("Invalid --search-limit: %d", limit), "Use a positive number of results")
+	}
+	if limit > github.MaxSearchResults {
+		util.Warn("GitHub search returns at most %d results; limiting to %d", github.MaxSearchResults, github.MaxSearchResults)
+	}
+
+	util.Info("Searching %s for: %s", host, query)
+	targets, err := s.client(host).SearchIssues(query, limit)
+	if err != nil {
+		util.Debug("Search failed: %v", err)
+		return nil, util.NewNetworkError("Failed to search issues", err)
+	}
+	if len(targets) == limit {
+		util.Warn("Search results capped at %d; use --search-limit to process more", limit)
+	}
+	if len(targets) == 0 {
+		util.Warn("No issues or pull requests match the search")
+	} else {
+		util.Success("Search matched %d issues and pull requests", len(targets))
+	}
+	return targets, nil
+}
+
+// readTargetList reads whitespace-separated targets from a file or, for "-",
+// from stdin. Blank lines and lines starting with # are ignored.
+func readTargetList(path string, stdin io.Reader) ([]string, error) {
+	reader := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var inputs []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		inputs = append(inputs, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
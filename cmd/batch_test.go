@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kojikawamura/gh-ccimg/github"
+	"github.com/kojikawamura/gh-ccimg/util"
+)
+
+var testHosts = github.Hosts{Default: github.DefaultHost, Known: []string{"ghe.example.com"}}
+
+func targetStrings(targets []*github.Target) []string {
+	var out []string
+	for _, target := range targets {
+		out = append(out, target.String())
+	}
+	return out
+}
+
+func TestCollectTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		want      []string
+		wantBatch bool
+	}{
+		{"single target", []string{"owner/repo#1"}, []string{"owner/repo#1"}, false},
+		{"multiple targets", []string{"owner/repo#1", "https://github.com/owner/other/pull/2"}, []string{"owner/repo#1", "owner/other#2"}, true},
+		{"range", []string{"owner/repo#3-5"}, []string{"owner/repo#3", "owner/repo#4", "owner/repo#5"}, true},
+		{"duplicates dropped", []string{"owner/repo#1-2", "Owner/Repo#2", "https://github.com/owner/repo/issues/1"}, []string{"owner/repo#1", "owner/repo#2"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, batch, err := collectTargets(tt.args, "", nil, testHosts)
+			if err != nil {
+				t.Fatalf("collectTargets failed: %v", err)
+			}
+			if got := targetStrings(targets); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+			if batch != tt.wantBatch {
+				t.Errorf("batch = %v, want %v", batch, tt.wantBatch)
+			}
+		})
+	}
+}
+
+func TestCollectTargets_TargetsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	content := "# morning triage\nowner/repo#10\n\n  owner/repo#11 ghe.example.com/team/app#3  \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, batch, err := collectTargets([]string{"owner/repo#1"}, path, nil, testHosts)
+	if err != nil {
+		t.Fatalf("collectTargets failed: %v", err)
+	}
+	want := "owner/repo#1 owner/repo#10 owner/repo#11 ghe.example.com/team/app#3"
+	if got := strings.Join(targetStrings(targets), " "); got != want {
+		t.Errorf("targets = %q, want %q", got, want)
+	}
+	if !batch {
+		t.Error("A targets file should always run in batch mode")
+	}
+}
+
+func TestCollectTargets_Stdin(t *testing.T) {
+	targets, batch, err := collectTargets(nil, "-", strings.NewReader("owner/repo#7\n"), testHosts)
+	if err != nil {
+		t.Fatalf("collectTargets failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0].String() != "owner/repo#7" || !batch {
+		t.Errorf("targets = %v, batch = %v", targetStrings(targets), batch)
+	}
+}
+
+func TestCollectTargets_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		file    string
+		stdin   string
+		wantErr string
+	}{
+		{name: "invalid target", args: []string{"owner/repo#1", "not-a-target"}, wantErr: "Invalid target format: not-a-target"},
+		{name: "reversed range", args: []string{"owner/repo#9-3"}, wantErr: "Invalid target format"},
+		{name: "missing file", file: filepath.Join(os.TempDir(), "does-not-exist-targets.txt"), wantErr: "Failed to read targets file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := collectTargets(tt.args, tt.file, strings.NewReader(tt.stdin), testHosts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("collectTargets error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCollectTargets_EmptyList(t *testing.T) {
+	targets, batch, err := collectTargets(nil, "-", strings.NewReader("# nothing\n"), testHosts)
+	if err != nil {
+		t.Fatalf("collectTargets failed: %v", err)
+	}
+	if len(targets) != 0 || !batch {
+		t.Errorf("targets = %v, batch = %v, want none in batch mode", targetStrings(targets), batch)
+	}
+}
+
+func TestDedupeTargets(t *testing.T) {
+	targets := []*github.Target{
+		{Host: "github.com", Owner: "org", Repo: "app", Number: "1"},
+		{Host: "github.com", Owner: "Org", Repo: "App", Number: "1"},
+		{Host: "ghe.example.com", Owner: "org", Repo: "app", Number: "1"},
+	}
+
+	got := targetStrings(dedupeTargets(targets))
+	if strings.Join(got, " ") != "org/app#1 ghe.example.com/org/app#1" {
+		t.Errorf("dedupeTargets = %v", got)
+	}
+}
+
+func TestRootCmd_NoTargets(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	targetsFile = filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(targetsFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{
+		Use:  "gh-ccimg <issue_url_or_target>...",
+		RunE: rootCmd.RunE,
+	}
+	cmd.SetArgs([]string{})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "No targets given") {
+		t.Errorf("Expected no targets error, got: %v", err)
+	}
+}
+
+func TestBatchOutputDir(t *testing.T) {
+	dir, err := batchOutputDir("", &github.Target{Owner: "o", Repo: "r", Number: "1"})
+	if err != nil || dir != "" {
+		t.Errorf("Memory mode should have no output directory, got %q, %v", dir, err)
+	}
+
+	tests := []struct {
+		target github.Target
+		want   string
+	}{
+		{github.Target{Host: "github.com", Owner: "owner", Repo: "repo", Number: "12"}, filepath.Join("out", "owner-repo-12")},
+		{github.Target{Host: "ghe.example.com", Owner: "team", Repo: "app.web", Number: "3"}, filepath.Join("out", "ghe.example.com-team-app.web-3")},
+		{github.Target{Owner: "owner", Repo: "repo", Number: "12", CommentKind: github.CommentKindIssue, CommentID: 99}, filepath.Join("out", "owner-repo-12-issuecomment-99")},
+	}
+	for _, tt := range tests {
+		dir, err := batchOutputDir("out", &tt.target)
+		if err != nil {
+			t.Fatalf("batchOutputDir failed: %v", err)
+		}
+		if dir != tt.want {
+			t.Errorf("batchOutputDir(%s) = %q, want %q", tt.target, dir, tt.want)
+		}
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	first := util.NewNetworkError("Failed to fetch issue/PR data", errors.New("HTTP 404"))
+	err := batchError(2, 5, first)
+
+	if !strings.Contains(err.Error(), "2 of 5 targets failed") {
+		t.Errorf("Unexpected message: %v", err)
+	}
+	if util.GetExitCode(err) != util.GetExitCode(first) {
+		t.Errorf("Exit code = %d, want %d", util.GetExitCode(err), util.GetExitCode(first))
+	}
+	if !errors.Is(err, first) {
+		t.Error("batchError should wrap the first failure")
+	}
+}
+
+func TestRootCmd_BatchRejectsSend(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	sendPrompt = "Analyze"
+
+	cmd := &cobra.Command{
+		Use:  "gh-ccimg <issue_url_or_target>...",
+		RunE: rootCmd.RunE,
+	}
+	cmd.SetArgs([]string{"owner/repo#1", "owner/repo#2"})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--send cannot be combined") {
+		t.Errorf("Expected --send batch error, got: %v", err)
+	}
+}
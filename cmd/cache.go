@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kojikawamura/gh-ccimg/httpcache"
+	"github.com/kojikawamura/gh-ccimg/util"
+)
+
+var (
+	pruneOlderThan time.Duration
+	pruneAll       bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local HTTP cache",
+	Long: `gh-ccimg caches GitHub API responses and images on disk and revalidates
+them with conditional requests, so re-running on a thread costs almost
+nothing. Use --no-cache on the main command to bypass the cache.`,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cache entries that have not been used recently",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupLogging()
+
+		dir, err := httpcache.DefaultDir()
+		if err != nil {
+			return util.NewFileSystemError("Failed to locate cache directory", err)
+		}
+
+		maxAge := pruneOlderThan
+		if pruneAll {
+			maxAge = 0
+		} else if maxAge <= 0 {
+			return util.NewValidationError("--older-than must be positive", "Use --all to empty the cache")
+		}
+
+		util.Debug("Pruning HTTP cache in %s (older than %v)", dir, maxAge)
+		removed, err := httpcache.New(dir).Prune(maxAge)
+		if err != nil {
+			return util.NewFileSystemError("Failed to prune cache", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cache entries from %s\n", removed, dir)
+		return nil
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 30*24*time.Hour, "Remove entries not used within this duration")
+	cachePruneCmd.Flags().BoolVar(&pruneAll, "all", false, "Remove every cache entry")
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
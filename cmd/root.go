@@ -2,17 +2,21 @@ package cmd
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
-	"time"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/kojikawamura/gh-ccimg/claude"
 	"github.com/kojikawamura/gh-ccimg/download"
 	"github.com/kojikawamura/gh-ccimg/github"
+	"github.com/kojikawamura/gh-ccimg/imaging"
 	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/output"
 	"github.com/kojikawamura/gh-ccimg/security"
 	"github.com/kojikawamura/gh-ccimg/storage"
 	"github.com/kojikawamura/gh-ccimg/util"
@@ -28,10 +32,39 @@ var (
 	verbose     bool
 	quiet       bool
 	debug       bool
+	formatName  string
+	targetsFile string
+	searchQuery string
+	searchLimit int
+	noCache     bool
+	syncDir     bool
+	syncPrune   bool
+	nameTmpl    string
+	dedupeName  string
+	similarity  int
+	resize      bool
+	maxEdge     int
+	megapixels  float64
+	quality     int
+	convertTo   string
+	stripMeta   bool
+	gifFrames   string
+	sheet       bool
+	sheetCols   int
+	sheetSize   int
+
+	// Comment filters
+	authors        []string
+	excludeAuthors []string
+	since          string
+	until          string
+	bodyOnly       bool
+	commentsOnly   bool
+	lastN          int
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "gh-ccimg <issue_url_or_target>",
+	Use:   "gh-ccimg <issue_url_or_target>...",
 	Short: "Extract images from GitHub issues and pull requests",
 	Long: `gh-ccimg extracts all images from GitHub issues and pull requests,
 with optional direct integration to Claude Code for AI-powered analysis.
@@ -39,9 +72,15 @@ with optional direct integration to Claude Code for AI-powered analysis.
 Examples:
   gh-ccimg OWNER/REPO#123
   gh-ccimg https://github.com/OWNER/REPO/issues/123
+  gh-ccimg https://github.com/OWNER/REPO/discussions/45
+  gh-ccimg https://github.com/OWNER/REPO/issues/123#issuecomment-456789
   gh-ccimg OWNER/REPO#123 --out ./images
-  gh-ccimg OWNER/REPO#123 --send "Analyze these screenshots"`,
-	Args: cobra.RangeArgs(0, 1),
+  gh-ccimg OWNER/REPO#123 --send "Analyze these screenshots"
+  gh-ccimg OWNER/REPO#123 --format ndjson
+  gh-ccimg OWNER/REPO#100-120 OWNER/REPO#200 --out ./triage
+  gh-ccimg --targets-file bugs.txt --format ndjson
+  gh-ccimg --search "repo:OWNER/REPO label:ui is:open" --out ./ui-bugs`,
+	Args: cobra.ArbitraryArgs,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// Handle version flag
 		if version, _ := cmd.Flags().GetBool("version"); version {
@@ -49,9 +88,9 @@ Examples:
 			os.Exit(0)
 		}
 		
-		// If not version flag, we need exactly 1 argument
-		if len(args) != 1 {
-			return fmt.Errorf("accepts 1 arg(s), received %d", len(args))
+		// If not version flag, we need at least one target
+		if len(args) == 0 && targetsFile == "" && searchQuery == "" {
+			return fmt.Errorf("requires at least 1 target argument, --targets-file or --search, received 0")
 		}
 		
 		return nil
@@ -60,174 +99,141 @@ Examples:
 		// Set up logging based on flags
 		setupLogging()
 		
-		target := args[0]
-		util.Info("Processing target: %s", target)
-		
-		// Step 1: Parse target
-		util.Verbose("Parsing target URL/string...")
-		util.Debug("Input target: %s", target)
-		owner, repo, num, err := github.ParseTarget(target)
+		format, err := output.ParseFormat(formatName)
 		if err != nil {
-			util.Debug("Parse error: %v", err)
-			return util.NewValidationError(fmt.Sprintf("Invalid target format: %s", target), 
-				"Use format: OWNER/REPO#NUM or https://github.com/OWNER/REPO/issues/NUM")
+			return util.NewValidationError(err.Error(), "Use --format text, --format json or --format ndjson")
 		}
-		util.Verbose("Parsed: %s/%s#%s", owner, repo, num)
-		util.Debug("Parsed components - Owner: %s, Repo: %s, Number: %s", owner, repo, num)
-
-		// Step 2: Check prerequisites
-		util.Debug("Checking prerequisites...")
-		if err := checkPrerequisites(); err != nil {
-			util.Debug("Prerequisites check failed: %v", err)
+		
+		// Step 1: Parse targets
+		util.Verbose("Parsing targets...")
+		util.Debug("Input targets: %v, targets file: %q", args, targetsFile)
+		hosts := github.ConfiguredHosts()
+		targets, batch, err := collectTargets(args, targetsFile, os.Stdin, hosts)
+		if err != nil {
 			return err
 		}
-		util.Debug("Prerequisites check passed")
-
-		// Step 3: Fetch GitHub data
-		util.Info("Fetching GitHub data...")
-		util.Debug("Creating GitHub client with timeout: %ds", timeout)
-		client := github.NewClient(time.Duration(timeout) * time.Second)
-		
-		util.Debug("Fetching issue/PR data from GitHub API...")
-		issue, err := client.FetchIssue(owner, repo, num)
+		if searchQuery != "" {
+			// Search results are always processed as a batch
+			batch = true
+		} else if len(targets) == 0 {
+			return util.NewValidationError("No targets given", "Pass OWNER/REPO#NUM arguments, a non-empty --targets-file or --search")
+		}
+		filter, err := buildCommentFilter()
 		if err != nil {
-			util.Debug("Failed to fetch issue: %v", err)
-			return util.NewNetworkError("Failed to fetch issue/PR data", err)
+			return err
 		}
-		util.Debug("Issue fetched successfully, body length: %d characters", len(issue.Body))
-		
-		util.Debug("Fetching comments from GitHub API...")
-		comments, err := client.FetchComments(owner, repo, num)
+		if syncDir && outDir == "" {
+			return util.NewValidationError("--sync requires --out", "Pass the directory to keep in sync with --out")
+		}
+		dedupe, err := storage.ParseDedupeMode(dedupeName)
 		if err != nil {
-			util.Debug("Failed to fetch comments: %v", err)
-			return util.NewNetworkError("Failed to fetch comments", err)
+			return util.NewValidationError(err.Error(), "Use --dedupe skip, --dedupe link or --dedupe off")
 		}
-		util.Verbose("Fetched issue and %d comments", len(comments))
-		util.Debug("Comments fetched successfully, count: %d", len(comments))
-
-		// Step 4: Extract image URLs
-		util.Info("Extracting image URLs from markdown...")
-		util.Debug("Starting image URL extraction from markdown content")
-		var allURLs []string
-		
-		// From issue body
-		util.Debug("Extracting URLs from issue body...")
-		issueURLs := markdown.ExtractImageURLs(issue.Body)
-		util.Debug("Found %d URLs in issue body", len(issueURLs))
-		for i, url := range issueURLs {
-			util.Debug("Issue URL %d: %s", i+1, url)
+		if similarity < 0 || similarity > imaging.MaxDistance {
+			return util.NewValidationError(fmt.Sprintf("--dedupe-similar must be between 0 and %d", imaging.MaxDistance),
+				"Use a small threshold such as 6; 0 disables near-duplicate detection")
 		}
-		allURLs = append(allURLs, issueURLs...)
-		
-		// From comments
-		util.Debug("Extracting URLs from %d comments...", len(comments))
-		for i, comment := range comments {
-			commentURLs := markdown.ExtractImageURLs(comment.Body)
-			util.Debug("Found %d URLs in comment %d", len(commentURLs), i+1)
-			for j, url := range commentURLs {
-				util.Debug("Comment %d URL %d: %s", i+1, j+1, url)
-			}
-			allURLs = append(allURLs, commentURLs...)
+		if quality < 1 || quality > 100 {
+			return util.NewValidationError("--quality must be between 1 and 100", fmt.Sprintf("The default is %d", imaging.DefaultQuality))
 		}
-		
-		if len(allURLs) == 0 {
-			util.Debug("No image URLs found in any markdown content")
-			util.Warn("No images found in issue/PR %s/%s#%s", owner, repo, num)
-			return nil
+		var convert imaging.Format
+		if convertTo != "" {
+			if convert, err = imaging.ParseFormat(convertTo); err != nil {
+				return util.NewValidationError(err.Error(), "Use --convert-to png or --convert-to jpeg")
+			}
 		}
-		util.Success("Found %d image URLs", len(allURLs))
-		util.Debug("Total unique URLs to download: %d", len(allURLs))
-
-		// Step 5: Download images
-		util.Info("Downloading images...")
-		maxSizeBytes := maxSize * 1024 * 1024 // Convert MB to bytes
-		util.Debug("Download configuration - Max size: %d MB (%d bytes), Timeout: %ds, Concurrency: 5", maxSize, maxSizeBytes, timeout)
-		fetcher := download.NewFetcher(maxSizeBytes, time.Duration(timeout)*time.Second, 5)
-		
-		// Set up progress reporting
-		if verbose || debug {
-			reporter := download.NewConsoleReporter(os.Stderr, true)
-			fetcher.SetReporter(reporter)
-		} else if !quiet {
-			reporter := download.NewConsoleReporter(os.Stderr, false)
-			fetcher.SetReporter(reporter)
+		var frames *imaging.FrameSelection
+		if gifFrames != "" {
+			selection, err := imaging.ParseFrameSelection(gifFrames)
+			if err != nil {
+				return util.NewValidationError(err.Error(), "Use --gif-frames 4, --gif-frames all or --gif-frames keyframes")
+			}
+			frames = &selection
 		}
-		
-		util.Debug("Starting concurrent download of %d URLs...", len(allURLs))
-		ctx := context.Background()
-		results := fetcher.FetchConcurrent(ctx, allURLs)
-		
-		// Count successful downloads and log failures
-		successCount := 0
-		var successfulResults []download.Result
-		var failureReasons []string
-		for _, result := range results {
-			if result.Error == nil {
-				successCount++
-				successfulResults = append(successfulResults, result)
-				util.Debug("Successfully downloaded %s (%d bytes, %s)", result.URL, result.Size, result.ContentType)
-			} else {
-				util.Verbose("Failed to download %s: %v", result.URL, result.Error)
-				util.Debug("Download failure for %s: %v", result.URL, result.Error)
-				failureReasons = append(failureReasons, fmt.Sprintf("%s: %v", result.URL, result.Error))
+		var sheetOpts *imaging.SheetOptions
+		if sheet {
+			sheetOpts = &imaging.SheetOptions{Columns: sheetCols, MaxSize: sheetSize}
+			if err := sheetOpts.Validate(); err != nil {
+				return util.NewValidationError(err.Error(), "Use fewer --sheet-columns or a larger --sheet-size")
 			}
 		}
-		
-		if successCount == 0 {
-			util.Debug("All downloads failed. Failure summary: %v", failureReasons)
-			suggestion := "Check that the URLs are accessible and contain valid images. Use --debug for detailed error information"
-			if len(failureReasons) > 0 {
-				suggestion += fmt.Sprintf(". Common issues: network connectivity, rate limiting, invalid URLs, or files too large (current limit: %dMB)", maxSize)
+		var resizeOpts *imaging.ResizeOptions
+		if resize {
+			if maxEdge < 0 || megapixels < 0 {
+				return util.NewValidationError("--max-dimension and --max-megapixels cannot be negative", "Use 0 to disable a limit")
 			}
-			return util.NewValidationError("No images could be downloaded", suggestion)
-		}
-		util.Success("Downloaded %d/%d images successfully", successCount, len(allURLs))
-		util.Debug("Download completed. Success: %d, Failures: %d", successCount, len(allURLs)-successCount)
-
-		// Step 6: Store images
-		var imageData []string
-		if outDir != "" {
-			// Disk storage mode
-			util.Info("Saving images to disk...")
-			if err := security.ValidateOutputPath(".", outDir); err != nil {
-				return util.NewSecurityError(fmt.Sprintf("Invalid output directory: %v", err))
+			resizeOpts = &imaging.ResizeOptions{
+				MaxDimension: maxEdge,
+				MaxPixels:    int(megapixels * 1e6),
+				MaxBytes:     imaging.DefaultMaxBytes,
+				Quality:      quality,
 			}
-			
-			diskStorage, err := storage.NewDiskStorage(outDir, force)
+		}
+		var names *storage.NameTemplate
+		if nameTmpl != "" {
+			if outDir == "" {
+				return util.NewValidationError("--name-template requires --out", "Pass the directory to save images to with --out")
+			}
+			names, err = storage.ParseNameTemplate(nameTmpl)
 			if err != nil {
-				return util.NewFileSystemError("Failed to initialize disk storage", err)
+				return util.NewValidationError(err.Error(), "See --help for the fields a name template can use")
 			}
-			
-			for _, result := range successfulResults {
-				filePath, err := diskStorage.Store(result.Data, result.ContentType, result.URL)
-				if err != nil {
-					util.Warn("Failed to save %s: %v", result.URL, err)
-					continue
-				}
-				imageData = append(imageData, filePath)
-				util.Verbose("Saved %s", filePath)
+		}
+		if syncPrune && !syncDir {
+			return util.NewValidationError("--prune requires --sync", "Add --sync to prune a synced output directory")
+		}
+		if batch && sendPrompt != "" {
+			return util.NewValidationError("--send cannot be combined with multiple targets",
+				"Run gh-ccimg once per target to send its images to Claude")
+		}
+
+		// Step 2: Check prerequisites
+		util.Debug("Checking prerequisites...")
+		if err := checkPrerequisites(); err != nil {
+			util.Debug("Prerequisites check failed: %v", err)
+			return err
+		}
+		util.Debug("Prerequisites check passed")
+
+		s := newSession(format, batch, filter)
+		s.names = names
+		s.dedupe = dedupe
+		s.similar = similarity
+		s.resize = resizeOpts
+		s.convert = convert
+		s.frames = frames
+		s.sheets = sheetOpts
+		s.quality = quality
+		// Images sent to Claude lose their metadata unless asked otherwise
+		s.strip = stripMeta || (sendPrompt != "" && !cmd.Flags().Changed("strip-metadata"))
+		if searchQuery != "" {
+			found, err := s.search(hosts.Default, searchQuery, searchLimit)
+			if err != nil {
+				return err
 			}
-			
-			util.Success("Saved %d images to %s", len(imageData), outDir)
-		} else {
-			// Memory storage mode
-			util.Info("Encoding images to base64...")
-			memStorage := storage.NewMemoryStorage()
-			
-			for _, result := range successfulResults {
-				encoded, err := memStorage.Store(result.Data, result.ContentType, result.URL)
+			targets = dedupeTargets(append(targets, found...))
+		}
+		if batch {
+			return s.runBatch(targets)
+		}
+		
+		target := targets[0]
+		result, err := s.processTarget(target, outDir)
+		if result != nil {
+			if writeErr := writeReport(format, result.report); writeErr != nil {
 				if err != nil {
-					util.Warn("Failed to encode %s: %v", result.URL, err)
-					continue
+					util.Debug("Failed to write report: %v", writeErr)
+				} else {
+					return util.NewFileSystemError("Failed to write output", writeErr)
 				}
-				imageData = append(imageData, encoded)
 			}
-			
-			// Output base64 strings
-			for i, encoded := range imageData {
-			fmt.Printf("Image %d (base64): %s\n", i+1, encoded)
-			}
-			util.Success("Encoded %d images to base64", len(imageData))
+		}
+		if err != nil {
+			return err
+		}
+		if len(result.downloads) == 0 && len(result.images) == 0 {
+			// No images were found; processTarget has already warned
+			return nil
 		}
 
 		// Step 7: Claude integration (if requested)
@@ -235,7 +241,7 @@ Examples:
 			util.Info("Sending to Claude...")
 			
 			// Security warning for sensitive data
-			warnSensitiveData(successfulResults, owner, repo, num)
+			warnSensitiveData(result.downloads, target.Owner, target.Repo, target.Number)
 			
 			// Validate Claude integration
 			if err := claude.IsClaudeAvailable(); err != nil {
@@ -243,15 +249,15 @@ Examples:
 					"Install Claude CLI or remove --send flag")
 			}
 			
-			if err := claude.ValidateClaudeInput(sendPrompt, imageData); err != nil {
+			if err := claude.ValidateClaudeInput(sendPrompt, result.images); err != nil {
 				return util.NewValidationError(fmt.Sprintf("Invalid Claude input: %v", err), 
 					"Check your prompt and ensure images were downloaded")
 			}
 			
 			// Execute Claude
 			sanitizedPrompt := claude.SanitizePrompt(sendPrompt)
-			util.Debug("Executing Claude with prompt length: %d characters, image count: %d", len(sanitizedPrompt), len(imageData))
-			if err := claude.ExecuteClaude(sanitizedPrompt, imageData, continueCmd); err != nil {
+			util.Debug("Executing Claude with prompt length: %d characters, image count: %d", len(sanitizedPrompt), len(result.images))
+			if err := claude.ExecuteClaude(sanitizedPrompt, result.images, continueCmd); err != nil {
 				util.Debug("Claude execution failed: %v", err)
 				return util.NewClaudeError("Claude execution failed", err)
 			}
@@ -264,6 +270,588 @@ Examples:
 	},
 }
 
+// targetResult holds everything produced for a single target
+type targetResult struct {
+	report    *output.Report
+	downloads []download.Result // Successful downloads
+	images    []string          // Stored file paths, or base64 data in memory mode
+}
+
+// processTarget fetches, downloads and stores the images of one target into
+// dir, or into memory when dir is empty. The result is nil if the issue
+// could not be fetched; otherwise it carries a report even on error.
+func (s *session) processTarget(target *github.Target, dir string) (*targetResult, error) {
+	owner, repo, num := target.Owner, target.Repo, target.Number
+	result := &targetResult{report: output.NewReport(target.String())}
+	report := result.report
+	util.Info("Processing target: %s", target)
+	util.Debug("Parsed components - Host: %s, Owner: %s, Repo: %s, Number: %s", target.Host, owner, repo, num)
+
+	// Step 3: Fetch GitHub data
+	util.Info("Fetching GitHub data...")
+	issue, comments, err := s.fetchThread(target)
+	if err != nil {
+		return nil, err
+	}
+	github.SortComments(comments)
+
+	// --prune keeps every image still in the thread, including those the
+	// comment filters leave out of this run
+	var referenced map[string]bool
+	if syncDir {
+		referenced = threadImageURLs(issue, comments, target.Host)
+	}
+
+	// Apply comment filters
+	includeBody := issue != nil && !commentsOnly && s.filter.Matches(issue.User.Login, issue.CreatedAt)
+	if bodyOnly {
+		comments = nil
+	} else {
+		comments = s.filter.Apply(comments)
+	}
+	util.Debug("After filters: body included: %v, %d comments", includeBody, len(comments))
+
+	// Step 4: Extract image URLs
+	// Order is issue body first, then comments chronologically, then
+	// position within each body, so numbering is stable across runs.
+	util.Info("Extracting image URLs from markdown...")
+	util.Debug("Starting image URL extraction from markdown content")
+	var allRefs []markdown.ImageRef
+	
+	// From issue body, unless only a single comment was requested or the
+	// body was filtered out
+	if includeBody {
+		util.Debug("Extracting URLs from issue body...")
+		bodyKind := markdown.SourceIssueBody
+		if target.IsDiscussion() {
+			bodyKind = markdown.SourceDiscussionBody
+		}
+		issueRefs := markdown.ExtractImageRefs(issue.Body, markdown.Source{
+			Kind:      bodyKind,
+			Author:    issue.User.Login,
+			CreatedAt: issue.CreatedAt,
+
+			AuthorAssociation: issue.AuthorAssociation,
+		}, target.Host)
+		issueRefs = markdown.ResolveSignedURLs(issueRefs, issue.BodyHTML, target.Host)
+		util.Debug("Found %d URLs in issue body", len(issueRefs))
+		for i, ref := range issueRefs {
+			util.Debug("Issue URL %d: %s", i+1, download.DisplayURL(ref.URL))
+		}
+		allRefs = append(allRefs, issueRefs...)
+	}
+	
+	// From comments
+	util.Debug("Extracting URLs from %d comments...", len(comments))
+	for i, comment := range comments {
+		commentRefs := markdown.ExtractImageRefs(comment.Body, commentSource(comment), target.Host)
+		commentRefs = markdown.ResolveSignedURLs(commentRefs, comment.BodyHTML, target.Host)
+		util.Debug("Found %d URLs in comment %d", len(commentRefs), i+1)
+		for j, ref := range commentRefs {
+			util.Debug("Comment %d URL %d: %s", i+1, j+1, download.DisplayURL(ref.URL))
+		}
+		allRefs = append(allRefs, commentRefs...)
+	}
+	
+	// In sync mode only images missing from the directory's manifest are
+	// downloaded; fetch[i] reports whether allRefs[i] is one of them and
+	// positions maps each of fetchRefs back to its index in allRefs
+	fetch := make([]bool, len(allRefs))
+	fetchRefs := make([]markdown.ImageRef, 0, len(allRefs))
+	positions := make([]int, 0, len(allRefs))
+	var manifest *storage.Manifest
+	if syncDir && dir != "" {
+		manifest, err = storage.LoadManifest(dir)
+		if err != nil {
+			return result, util.NewFileSystemError("Failed to load sync manifest", err)
+		}
+	}
+	for i, ref := range allRefs {
+		if manifest != nil {
+			if _, ok := manifest.Lookup(ref.URL); ok {
+				continue
+			}
+			if _, ok := manifest.LookupFrames(ref.URL); ok && s.frames != nil {
+				continue
+			}
+		}
+		fetch[i] = true
+		fetchRefs = append(fetchRefs, ref)
+		positions = append(positions, i)
+	}
+	
+	if len(allRefs) == 0 {
+		util.Debug("No image URLs found in any markdown content")
+		util.Warn("No images found in issue/PR %s", target)
+		if manifest != nil {
+			finishSync(manifest, referenced)
+		}
+		return result, nil
+	}
+	util.Success("Found %d image URLs", len(allRefs))
+	if manifest != nil {
+		util.Info("%d of %d images already synced", len(allRefs)-len(fetchRefs), len(allRefs))
+	}
+	util.Debug("Total unique URLs to download: %d", len(fetchRefs))
+
+	// Step 5: Download images
+	var results []download.Result
+	if len(fetchRefs) > 0 {
+		util.Info("Downloading images...")
+		util.Debug("Starting concurrent download of %d URLs...", len(fetchRefs))
+		ctx := context.Background()
+		results = s.fetcher.FetchImages(ctx, fetchRefs)
+		
+		// Number results among all extracted images rather than only the
+		// ones fetched, so failure positions hold up when synced images
+		// were skipped
+		for i := range results {
+			results[i].Index = positions[i]
+		}
+	}
+	
+	// Count successful downloads and log failures
+	successCount := 0
+	var failureReasons []string
+	for _, res := range results {
+		if res.Error == nil {
+			successCount++
+			result.downloads = append(result.downloads, res)
+			util.Debug("Successfully downloaded %s (%d bytes, %s)", download.DisplayURL(res.URL), res.Size, res.ContentType)
+		} else {
+			util.Verbose("Failed to download %s: %v", download.DisplayURL(res.URL), res.Error)
+			util.Debug("Download failure for %s: %v", download.DisplayURL(res.URL), res.Error)
+			failureReasons = append(failureReasons, fmt.Sprintf("%s: %v", download.DisplayURL(res.URL), res.Error))
+			report.AddFailure(res)
+		}
+	}
+	
+	if successCount == 0 && len(fetchRefs) == len(allRefs) {
+		util.Debug("All downloads failed. Failure summary: %v", failureReasons)
+		suggestion := "Check that the URLs are accessible and contain valid images. Use --debug for detailed error information"
+		if len(failureReasons) > 0 {
+			suggestion += fmt.Sprintf(". Common issues: network connectivity, rate limiting, invalid URLs, or files too large (current limit: %dMB)", maxSize)
+		}
+		return result, util.NewValidationError("No images could be downloaded", suggestion)
+	}
+	util.Success("Downloaded %d/%d images successfully", successCount, len(fetchRefs))
+	util.Debug("Download completed. Success: %d, Failures: %d", successCount, len(fetchRefs)-successCount)
+
+	// Step 6: Store images
+	if dir != "" {
+		// Disk storage mode
+		util.Info("Saving images to disk...")
+		if err := security.ValidateOutputPath(".", dir); err != nil {
+			return result, util.NewSecurityError(fmt.Sprintf("Invalid output directory: %v", err))
+		}
+		
+		diskStorage, err := storage.NewDiskStorage(dir, force)
+		if err != nil {
+			return result, util.NewFileSystemError("Failed to initialize disk storage", err)
+		}
+		if manifest != nil {
+			diskStorage.SetManifest(manifest)
+		}
+		if s.names != nil {
+			diskStorage.SetNameTemplate(s.names)
+		}
+		diskStorage.SetDedupe(s.dedupe)
+		diskStorage.SetSimilarity(s.similar)
+		diskStorage.SetStripMetadata(s.strip)
+		
+		// Walk every reference in thread order, keeping images synced by an
+		// earlier run and storing the ones downloaded now
+		next := 0
+		for i, ref := range allRefs {
+			if !fetch[i] {
+				if filePath, ok := diskStorage.Keep(ref); ok {
+					util.Verbose("Kept %s (%s)", filePath, describeRef(ref))
+				} else if filePaths, ok := diskStorage.KeepFrames(ref); ok {
+					util.Verbose("Kept %d frames of %s", len(filePaths), describeRef(ref))
+				}
+				continue
+			}
+			res := results[next]
+			next++
+			if res.Error != nil {
+				continue
+			}
+			
+			// Frames are synced under their own URLs; the GIF they came from
+			// is recorded with them once all are saved, so later runs skip it
+			var frames []string
+			framesSaved := true
+			for _, item := range s.prepare(res, report) {
+				isFrame := item.Ref.URL != ref.URL
+				if isFrame {
+					frames = append(frames, item.Ref.URL)
+				}
+				stored := diskStorage.Count()
+				filePath, err := diskStorage.StoreRefAt(i, item.Data, item.ContentType, item.Ref)
+				if err != nil {
+					util.Warn("Failed to save %s: %v", download.DisplayURL(item.URL), err)
+					report.AddStorageFailure(item, err)
+					if isFrame {
+						framesSaved = false
+					}
+					continue
+				}
+				if diskStorage.Count() == stored {
+					util.Verbose("Skipped %s, duplicate of %s", describeRef(item.Ref), filePath)
+					continue
+				}
+				util.Verbose("Saved %s (%s)", filePath, describeRef(item.Ref))
+			}
+			if manifest != nil && len(frames) > 0 && framesSaved {
+				manifest.RecordFrames(ref.URL, frames)
+			}
+		}
+		if s.resize != nil {
+			s.resizeStored(diskStorage)
+		}
+		
+		// Kept and duplicate images are only listed once, and downscaled
+		// copies are what gets sent on
+		for _, entry := range diskStorage.Entries() {
+			logStripped(entry)
+			report.AddEntry(entry, false)
+			if entry.Resized != nil {
+				result.images = append(result.images, entry.Resized.Location)
+			} else {
+				result.images = append(result.images, entry.Location)
+			}
+		}
+		if manifest != nil {
+			finishSync(manifest, referenced)
+		}
+		util.Success("Saved %d images to %s", len(result.images), dir)
+		if s.sheets != nil {
+			sheets := s.contactSheets(diskStorage.Entries(), func(entry storage.Entry) ([]byte, error) {
+				return os.ReadFile(entry.Location)
+			})
+			variants, err := diskStorage.StoreSheets(sheets)
+			if err != nil {
+				util.Warn("Failed to save contact sheets: %v", err)
+			}
+			if len(variants) > 0 {
+				addSheets(result, sheets, variants, false)
+				util.Success("Saved %d contact sheets to %s", len(variants), dir)
+			}
+		}
+	} else {
+		// Memory storage mode
+		util.Info("Encoding images to base64...")
+		memStorage := storage.NewMemoryStorage()
+		memStorage.SetDedupe(s.dedupe)
+		memStorage.SetSimilarity(s.similar)
+		memStorage.SetStripMetadata(s.strip)
+		
+		for _, downloaded := range result.downloads {
+			for _, res := range s.prepare(downloaded, report) {
+				// Only the downscaled copy is kept in memory mode
+				if s.resize != nil {
+					if resized := s.resizeImage(res.Data, res.Ref); resized != nil {
+						res.Data, res.ContentType, res.Size = resized.Data, resized.ContentType, int64(len(resized.Data))
+					}
+				}
+				
+				stored := memStorage.Count()
+				encoded, err := memStorage.StoreRef(res.Data, res.ContentType, res.Ref)
+				if err != nil {
+					util.Warn("Failed to encode %s: %v", download.DisplayURL(res.URL), err)
+					report.AddStorageFailure(res, err)
+					continue
+				}
+				if memStorage.Count() == stored {
+					util.Verbose("Skipped %s, duplicate of an earlier image", describeRef(res.Ref))
+					continue
+				}
+				result.images = append(result.images, encoded)
+			}
+		}
+		
+		// Output base64 strings, labelled with their target in batch mode
+		prefix := ""
+		if s.batch {
+			prefix = fmt.Sprintf("[%s] ", target)
+		}
+		for i, entry := range memStorage.Entries() {
+			logStripped(entry)
+			report.AddEntry(entry, true)
+			if s.format == output.FormatText {
+				util.Verbose("Image %d: %s (%s)", i+1, download.DisplayURL(entry.Ref.URL), describeRef(entry.Ref))
+				fmt.Printf("%sImage %d (base64): %s\n", prefix, i+1, entry.Location)
+			}
+		}
+		util.Success("Encoded %d images to base64", len(result.images))
+		if s.sheets != nil {
+			sheets := s.contactSheets(memStorage.Entries(), func(entry storage.Entry) ([]byte, error) {
+				return base64.StdEncoding.DecodeString(entry.Location)
+			})
+			variants := memStorage.StoreSheets(sheets)
+			addSheets(result, sheets, variants, true)
+			if s.format == output.FormatText {
+				for i, variant := range variants {
+					fmt.Printf("%sContact sheet %d (base64): %s\n", prefix, i+1, variant.Location)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// logStripped reports the metadata removed from an image in verbose mode
+func logStripped(entry storage.Entry) {
+	if len(entry.Stripped) > 0 {
+		util.Verbose("Removed %s from %s", strings.Join(entry.Stripped, ", "), download.DisplayURL(entry.Ref.URL))
+	}
+}
+
+// contactSheets composes the stored images into contact sheets, labelled
+// with their index in the report. read returns the content of an entry.
+func (s *session) contactSheets(entries []storage.Entry, read func(storage.Entry) ([]byte, error)) []imaging.Sheet {
+	tiles := make([]imaging.Tile, 0, len(entries))
+	for i, entry := range entries {
+		data, err := read(entry)
+		if err != nil {
+			// The cell stays empty but keeps its label
+			util.Warn("Failed to read image %d for the contact sheet: %v", i+1, err)
+		}
+		tiles = append(tiles, imaging.Tile{Data: data, Label: i + 1})
+	}
+	sheets, err := imaging.ContactSheets(tiles, *s.sheets)
+	if err != nil {
+		util.Warn("Failed to build contact sheets: %v", err)
+		return nil
+	}
+	return sheets
+}
+
+// addSheets records stored contact sheets in the report and makes them what
+// --send passes to Claude in place of the individual images
+func addSheets(result *targetResult, sheets []imaging.Sheet, variants []storage.Variant, inMemory bool) {
+	if len(variants) == 0 {
+		return
+	}
+	result.images = result.images[:0]
+	for i, variant := range variants {
+		result.report.AddSheet(variant, sheets[i].Labels, inMemory)
+		result.images = append(result.images, variant.Location)
+	}
+}
+
+// prepare turns a download into the images to store: the frames picked
+// from an animated GIF with --gif-frames, or else the image itself, each
+// converted to the --convert-to format. Doing this before storing keeps
+// files, data URIs and the report describing what is sent on.
+func (s *session) prepare(res download.Result, report *output.Report) []download.Result {
+	items := []download.Result{res}
+	if s.frames != nil {
+		items = s.extractFrames(res, report)
+	}
+	if s.convert != "" {
+		for i := range items {
+			s.convertResult(&items[i], report)
+		}
+	}
+	return items
+}
+
+// extractFrames splits an animated GIF into one PNG per selected frame,
+// each referenced by the GIF's URL with a #frame=N fragment. Still images
+// and GIFs that fail to decode are returned as they are.
+func (s *session) extractFrames(res download.Result, report *output.Report) []download.Result {
+	frames, total, err := imaging.ExtractFrames(res.Data, *s.frames)
+	if err != nil {
+		message := fmt.Sprintf("frames not extracted: %v", err)
+		util.Warn("Image %s %s", download.DisplayURL(res.URL), message)
+		report.Warn(res.URL, message)
+		return []download.Result{res}
+	}
+	if len(frames) == 0 {
+		return []download.Result{res}
+	}
+	util.Verbose("Extracted %d of %d frames from %s", len(frames), total, download.DisplayURL(res.URL))
+	
+	items := make([]download.Result, 0, len(frames))
+	for _, frame := range frames {
+		item := res
+		item.URL = fmt.Sprintf("%s#frame=%d", res.URL, frame.Index)
+		item.Ref.URL = item.URL
+		item.Data, item.ContentType, item.Size = frame.Data, "image/png", int64(len(frame.Data))
+		report.SetFrame(item.URL, output.Frame{
+			URL:      res.URL,
+			Index:    frame.Index,
+			Count:    total,
+			DelayMS:  frame.Delay.Milliseconds(),
+			OffsetMS: frame.Offset.Milliseconds(),
+		})
+		items = append(items, item)
+	}
+	return items
+}
+
+// convertResult re-encodes a downloaded image in the --convert-to format.
+// Images that cannot be decoded, such as SVG, are kept as they are
+// with a warning rather than failing the run.
+func (s *session) convertResult(res *download.Result, report *output.Report) {
+	converted, changed, err := imaging.Convert(res.Data, s.convert, s.quality)
+	if err != nil {
+		message := fmt.Sprintf("not converted to %s: %v", s.convert, err)
+		util.Warn("Image %s %s", download.DisplayURL(res.URL), message)
+		report.Warn(res.URL, message)
+		return
+	}
+	if !changed {
+		return
+	}
+	util.Verbose("Converted %s from %s to %s", download.DisplayURL(res.URL), res.ContentType, s.convert.ContentType())
+	res.Data, res.ContentType, res.Size = converted, s.convert.ContentType(), int64(len(converted))
+}
+
+// resizeStored saves a downscaled copy of every stored image that exceeds
+// the resize limits, reading images kept by an earlier sync run back from
+// disk
+func (s *session) resizeStored(diskStorage *storage.DiskStorage) {
+	count := 0
+	for _, entry := range diskStorage.Entries() {
+		data, err := os.ReadFile(entry.Location)
+		if err != nil {
+			util.Warn("Failed to read %s for resizing: %v", entry.Location, err)
+			continue
+		}
+		resized := s.resizeImage(data, entry.Ref)
+		if resized == nil {
+			continue
+		}
+		if _, err := diskStorage.StoreResized(entry.Location, *resized); err != nil {
+			util.Warn("Failed to save resized copy of %s: %v", entry.Location, err)
+			continue
+		}
+		count++
+	}
+	if count > 0 {
+		util.Success("Saved %d resized copies to %s", count, filepath.Join(diskStorage.GetOutputDir(), storage.ResizedDir))
+	}
+}
+
+// resizeImage downscales data to the resize limits. It returns nil when the
+// image already fits or is in a format that cannot be resized, such as
+// SVG, in which case the original is used as is.
+func (s *session) resizeImage(data []byte, ref markdown.ImageRef) *imaging.Resized {
+	resized, ok, err := imaging.Resize(data, *s.resize)
+	if err != nil {
+		util.Debug("Not resizing %s: %v", describeRef(ref), err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	util.Verbose("Resized %s to %dx%d %s (%d -> %d bytes)", describeRef(ref),
+		resized.Width, resized.Height, resized.ContentType, len(data), len(resized.Data))
+	return &resized
+}
+
+// finishSync prunes images whose URL is not in referenced when --prune is
+// set and writes the sync manifest back. Failures are only warned about
+// since the images themselves are already saved.
+func finishSync(manifest *storage.Manifest, referenced map[string]bool) {
+	if syncPrune {
+		removed, err := manifest.Prune(referenced)
+		for _, path := range removed {
+			util.Verbose("Removed %s, no longer referenced", path)
+		}
+		if err != nil {
+			util.Warn("Failed to prune synced images: %v", err)
+		} else if len(removed) > 0 {
+			util.Info("Removed %d images no longer referenced", len(removed))
+		}
+	}
+	if err := manifest.Save(); err != nil {
+		util.Warn("Failed to save sync manifest: %v", err)
+	}
+}
+
+// threadImageURLs returns the URL of every image in the body and comments
+// of a thread, regardless of comment filters
+func threadImageURLs(issue *github.Issue, comments []*github.Comment, host string) map[string]bool {
+	urls := make(map[string]bool)
+	if issue != nil {
+		for _, ref := range markdown.ExtractImageRefs(issue.Body, markdown.Source{}, host) {
+			urls[ref.URL] = true
+		}
+	}
+	for _, comment := range comments {
+		for _, ref := range markdown.ExtractImageRefs(comment.Body, commentSource(comment), host) {
+			urls[ref.URL] = true
+		}
+	}
+	return urls
+}
+
+// fetchThread retrieves the body and every comment of a target: issue
+// comments, plus reviews for pull requests, or comments and replies for
+// discussions. Comment permalink targets return only that comment and a nil
+// issue.
+func (s *session) fetchThread(target *github.Target) (*github.Issue, []*github.Comment, error) {
+	owner, repo, num := target.Owner, target.Repo, target.Number
+	client := s.client(target.Host)
+	
+	if target.IsComment() {
+		comments, err := fetchSingleComment(client, target)
+		if err != nil {
+			util.Debug("Failed to fetch %s: %v", target.Fragment(), err)
+			return nil, nil, util.NewNetworkError("Failed to fetch comment", err)
+		}
+		return nil, comments, nil
+	}
+	
+	if target.IsDiscussion() {
+		util.Debug("Fetching discussion, comments and replies from GitHub GraphQL API...")
+		discussion, comments, err := client.FetchDiscussion(owner, repo, num)
+		if err != nil {
+			util.Debug("Failed to fetch discussion: %v", err)
+			return nil, nil, util.NewNetworkError("Failed to fetch discussion data", err)
+		}
+		util.Verbose("Fetched discussion and %d comments and replies", len(comments))
+		return discussion, comments, nil
+	}
+	
+	// One paginated GraphQL query covers the issue or PR, its comments and,
+	// for pull requests, its reviews and inline review comments
+	util.Debug("Fetching issue/PR, comments and reviews from GitHub GraphQL API...")
+	issue, comments, err := client.FetchThread(owner, repo, num)
+	if err != nil {
+		util.Debug("Failed to fetch issue: %v", err)
+		return nil, nil, util.NewNetworkError("Failed to fetch issue/PR data", err)
+	}
+	util.Debug("Issue fetched successfully, body length: %d characters", len(issue.Body))
+	util.Verbose("Fetched issue and %d comments", len(comments))
+	return issue, comments, nil
+}
+
+// fetchSingleComment retrieves the one comment or review a permalink target points at
+func fetchSingleComment(client *github.Client, target *github.Target) ([]*github.Comment, error) {
+	util.Debug("Fetching %s only from GitHub API...", target.Fragment())
+	switch target.CommentKind {
+	case github.CommentKindReview:
+		return client.FetchReview(target.Owner, target.Repo, target.Number, target.CommentID)
+	case github.CommentKindReviewComment:
+		comment, err := client.FetchReviewComment(target.Owner, target.Repo, target.CommentID)
+		if err != nil {
+			return nil, err
+		}
+		return []*github.Comment{comment}, nil
+	default:
+		comment, err := client.FetchComment(target.Owner, target.Repo, target.CommentID)
+		if err != nil {
+			return nil, err
+		}
+		return []*github.Comment{comment}, nil
+	}
+}
+
 func init() {
 	rootCmd.Flags().StringVarP(&outDir, "out", "o", "", "Output directory for images (default: memory mode)")
 	rootCmd.Flags().StringVar(&sendPrompt, "send", "", "Send images to Claude with this prompt")
@@ -274,11 +862,67 @@ func init() {
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Quiet mode (errors only)")
 	rootCmd.Flags().BoolVar(&debug, "debug", false, "Debug mode (detailed troubleshooting info)")
+	rootCmd.Flags().StringVar(&formatName, "format", "text", "Output format: text, json or ndjson")
+	rootCmd.Flags().StringVar(&targetsFile, "targets-file", "", "Read additional targets from a file, one per line (- for stdin)")
+	rootCmd.Flags().StringVar(&searchQuery, "search", "", "Process every issue and PR matching a GitHub search query")
+	rootCmd.Flags().IntVar(&searchLimit, "search-limit", 50, "Maximum number of search results to process")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Bypass the local HTTP cache for API responses and images")
+	rootCmd.Flags().BoolVar(&syncDir, "sync", false, "Only download images not yet saved to --out, tracked in a manifest there")
+	rootCmd.Flags().BoolVar(&syncPrune, "prune", false, "With --sync, delete saved images that are no longer referenced")
+	rootCmd.Flags().StringVar(&dedupeName, "dedupe", "skip", "Identical images: skip (store once), link (hard-link copies in --out) or off")
+	rootCmd.Flags().IntVar(&similarity, "dedupe-similar", 0, "Skip images that look like an earlier one within this perceptual hash distance (1-64 bits, 0 disables)")
+	rootCmd.Flags().BoolVar(&resize, "resize", false, "Downscale and re-encode images to fit Claude's limits; originals are kept in --out")
+	rootCmd.Flags().IntVar(&maxEdge, "max-dimension", imaging.DefaultMaxDimension, "With --resize, longest edge in pixels (0 for no limit)")
+	rootCmd.Flags().Float64Var(&megapixels, "max-megapixels", imaging.DefaultMaxMegapixels, "With --resize, pixel budget in megapixels (0 for no limit)")
+	rootCmd.Flags().IntVar(&quality, "quality", imaging.DefaultQuality, "JPEG quality from 1 to 100 for --resize and --convert-to")
+	rootCmd.Flags().StringVar(&convertTo, "convert-to", "", "Convert images to png or jpeg before storing; formats that cannot be decoded are kept with a warning")
+	rootCmd.Flags().StringVar(&gifFrames, "gif-frames", "", "Split animated GIFs into PNG frames: a number of evenly spaced frames, all, or keyframes")
+	rootCmd.Flags().BoolVar(&sheet, "contact-sheet", false, "Compose the images into labelled grid PNGs and send those instead of the individual images")
+	rootCmd.Flags().IntVar(&sheetCols, "sheet-columns", imaging.DefaultSheetColumns, "With --contact-sheet, images per row")
+	rootCmd.Flags().IntVar(&sheetSize, "sheet-size", imaging.DefaultMaxDimension, "With --contact-sheet, maximum width and height of a sheet in pixels")
+	rootCmd.Flags().BoolVar(&stripMeta, "strip-metadata", false, "Remove EXIF (including GPS), XMP, ICC and text metadata from JPEG and PNG images before storing (default true with --send)")
+	rootCmd.Flags().StringVar(&nameTmpl, "name-template", "", "Go template for saved filenames, e.g. '{{.Author}}/{{.Date}}-{{.Hash}}'; fields: Index, Kind, CommentID, Author, Date, Alt, Hash, Basename")
+	rootCmd.Flags().StringSliceVar(&authors, "author", nil, "Only extract from content by these logins (* and ? wildcards allowed)")
+	rootCmd.Flags().StringSliceVar(&excludeAuthors, "exclude-author", nil, "Skip content by these logins, e.g. '*[bot]'")
+	rootCmd.Flags().StringVar(&since, "since", "", "Only extract from content created on or after this date (YYYY-MM-DD or RFC 3339)")
+	rootCmd.Flags().StringVar(&until, "until", "", "Only extract from content created before the end of this date (YYYY-MM-DD or RFC 3339)")
+	rootCmd.Flags().BoolVar(&bodyOnly, "body-only", false, "Only extract from the issue, PR or discussion body")
+	rootCmd.Flags().BoolVar(&commentsOnly, "comments-only", false, "Only extract from comments, skipping the body")
+	rootCmd.Flags().IntVar(&lastN, "last", 0, "Only extract from the N most recent matching comments")
 	
 	// Add version flag
 	rootCmd.Flags().BoolP("version", "V", false, "Show version information")
 }
 
+// buildCommentFilter validates the comment filter flags
+func buildCommentFilter() (github.CommentFilter, error) {
+	if bodyOnly && commentsOnly {
+		return github.CommentFilter{}, util.NewValidationError("--body-only and --comments-only cannot be combined",
+			"Use one of them, or neither to extract from the whole thread")
+	}
+	
+	sinceTime, err := github.ParseFilterTime(since, false)
+	if err != nil {
+		return github.CommentFilter{}, util.NewValidationError(fmt.Sprintf("Invalid --since: %v", err), "Use a date such as 2024-03-01")
+	}
+	untilTime, err := github.ParseFilterTime(until, true)
+	if err != nil {
+		return github.CommentFilter{}, util.NewValidationError(fmt.Sprintf("Invalid --until: %v", err), "Use a date such as 2024-03-31")
+	}
+	
+	filter := github.CommentFilter{
+		Authors:        authors,
+		ExcludeAuthors: excludeAuthors,
+		Since:          sinceTime,
+		Until:          untilTime,
+		Last:           lastN,
+	}
+	if err := filter.Validate(); err != nil {
+		return github.CommentFilter{}, util.NewValidationError(fmt.Sprintf("Invalid comment filter: %v", err), "Check --since, --until and --last")
+	}
+	return filter, nil
+}
+
 // setupLogging configures the logger based on command line flags
 func setupLogging() {
 	if quiet {
@@ -323,6 +967,49 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// writeReport emits the report on stdout for structured output formats.
+// Text output is written inline as images are stored, so it is a no-op there.
+func writeReport(format output.Format, report *output.Report) error {
+	if format == output.FormatText {
+		return nil
+	}
+	return output.Write(os.Stdout, format, report)
+}
+
+// commentSource describes where a comment lives for image provenance
+func commentSource(comment *github.Comment) markdown.Source {
+	source := markdown.Source{
+		Kind:      markdown.SourceComment,
+		CommentID: comment.ID,
+		Author:    comment.User.Login,
+		CreatedAt: comment.CreatedAt,
+
+		AuthorAssociation: comment.AuthorAssociation,
+	}
+	switch comment.Kind {
+	case github.CommentKindReview:
+		source.Kind = markdown.SourceReview
+	case github.CommentKindReviewComment:
+		source.Kind = markdown.SourceReviewComment
+		source.Path = comment.Path
+		source.Line = comment.Line
+	case github.CommentKindDiscussion:
+		source.Kind = markdown.SourceDiscussionComment
+	case github.CommentKindDiscussionReply:
+		source.Kind = markdown.SourceDiscussionReply
+	}
+	return source
+}
+
+// describeRef formats the provenance of an image reference for log output
+func describeRef(ref markdown.ImageRef) string {
+	desc := ref.Source.String()
+	if ref.Alt != "" {
+		desc += fmt.Sprintf(", alt %q", ref.Alt)
+	}
+	return desc
+}
+
 // warnSensitiveData displays security warnings about potentially sensitive data
 func warnSensitiveData(results []download.Result, owner, repo, num string) {
 	util.Warn("🔒 SECURITY WARNING: You are about to send image data to Claude")
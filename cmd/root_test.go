@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+
+	"github.com/kojikawamura/gh-ccimg/github"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/storage"
 )
 
 // Test helper functions
@@ -22,6 +27,19 @@ func resetFlags() {
 	verbose = false
 	quiet = false
 	debug = false
+	formatName = "text"
+	targetsFile = ""
+	searchQuery = ""
+	searchLimit = 50
+	authors = nil
+	excludeAuthors = nil
+	since = ""
+	until = ""
+	bodyOnly = false
+	commentsOnly = false
+	lastN = 0
+	syncDir = false
+	syncPrune = false
 }
 
 func captureOutput(f func()) (string, string) {
@@ -561,6 +579,27 @@ func TestRootCmd_InvalidTargetFormats(t *testing.T) {
 	}
 }
 
+func TestRootCmd_InvalidFormat(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	formatName = "xml"
+
+	cmd := &cobra.Command{
+		Use:  "gh-ccimg <issue_url_or_target>",
+		Args: cobra.ExactArgs(1),
+		RunE: rootCmd.RunE,
+	}
+	cmd.SetArgs([]string{"owner/repo#123"})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error for unsupported format")
+	}
+	if !strings.Contains(err.Error(), "unsupported output format") {
+		t.Errorf("Expected unsupported format error, got: %v", err)
+	}
+}
+
 func TestRootCmd_PrerequisiteChecks(t *testing.T) {
 	// Note: These tests will likely fail in the test environment since
 	// gh CLI and Claude CLI may not be available, but they test the
@@ -954,3 +993,111 @@ func TestCheckPrerequisites(t *testing.T) {
 	}
 }
 
+
+func TestCommentSource(t *testing.T) {
+	tests := []struct {
+		kind github.CommentKind
+		want markdown.SourceKind
+	}{
+		{github.CommentKindIssue, markdown.SourceComment},
+		{github.CommentKindReview, markdown.SourceReview},
+		{github.CommentKindReviewComment, markdown.SourceReviewComment},
+		{github.CommentKindDiscussion, markdown.SourceDiscussionComment},
+		{github.CommentKindDiscussionReply, markdown.SourceDiscussionReply},
+	}
+
+	for _, tt := range tests {
+		comment := &github.Comment{ID: 5, Kind: tt.kind, User: github.User{Login: "octocat"}, Path: "a.go", Line: 3}
+		source := commentSource(comment)
+		if source.Kind != tt.want || source.CommentID != 5 || source.Author != "octocat" {
+			t.Errorf("commentSource(%s) = %+v, want kind %s", tt.kind, source, tt.want)
+		}
+		if (source.Path != "") != (tt.kind == github.CommentKindReviewComment) {
+			t.Errorf("commentSource(%s) path = %q", tt.kind, source.Path)
+		}
+	}
+}
+
+func TestBuildCommentFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func()
+		wantErr string
+	}{
+		{name: "defaults", setup: func() {}},
+		{name: "all filters", setup: func() {
+			authors = []string{"alice"}
+			excludeAuthors = []string{"*[bot]"}
+			since = "2024-03-01"
+			until = "2024-03-31"
+			lastN = 3
+		}},
+		{name: "body and comments only", setup: func() { bodyOnly, commentsOnly = true, true }, wantErr: "cannot be combined"},
+		{name: "bad since", setup: func() { since = "last week" }, wantErr: "Invalid --since"},
+		{name: "bad until", setup: func() { until = "03/31/2024" }, wantErr: "Invalid --until"},
+		{name: "reversed range", setup: func() { since, until = "2024-04-01", "2024-03-01" }, wantErr: "Invalid comment filter"},
+		{name: "negative last", setup: func() { lastN = -2 }, wantErr: "Invalid comment filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags()
+			defer resetFlags()
+			tt.setup()
+
+			filter, err := buildCommentFilter()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("buildCommentFilter() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildCommentFilter() unexpected error: %v", err)
+			}
+			if filter.Last != lastN || len(filter.Authors) != len(authors) {
+				t.Errorf("buildCommentFilter() = %+v", filter)
+			}
+			if until != "" && filter.Until.Day() != 1 {
+				t.Errorf("--until %s should include the whole day, got %v", until, filter.Until)
+			}
+		})
+	}
+}
+
+func TestFinishSync_PruneKeepsFilteredComments(t *testing.T) {
+	resetFlags()
+	defer resetFlags()
+	syncDir, syncPrune = true, true
+
+	dir := t.TempDir()
+	manifest, err := storage.LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	for _, name := range []string{"body", "alice", "bob", "gone"} {
+		file := name + ".png"
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(name), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		manifest.Record("https://example.com/"+file, storage.ManifestEntry{File: file})
+	}
+
+	issue := &github.Issue{Body: "![body](https://example.com/body.png)", User: github.User{Login: "alice"}}
+	comments := []*github.Comment{
+		{ID: 1, Kind: github.CommentKindIssue, Body: "![a](https://example.com/alice.png)", User: github.User{Login: "alice"}},
+		{ID: 2, Kind: github.CommentKindIssue, Body: "![b](https://example.com/bob.png)", User: github.User{Login: "bob"}},
+	}
+	if filtered := (github.CommentFilter{Authors: []string{"alice"}}).Apply(comments); len(filtered) != 1 {
+		t.Fatalf("--author alice kept %d comments, want 1", len(filtered))
+	}
+
+	finishSync(manifest, threadImageURLs(issue, comments, "github.com"))
+
+	for name, want := range map[string]bool{"body": true, "alice": true, "bob": true, "gone": false} {
+		_, err := os.Stat(filepath.Join(dir, name+".png"))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s.png exists = %v, want %v", name, exists, want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package download
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetAuthToken registers a token to send when downloading from host.
+// The token is only attached to HTTPS requests whose host matches exactly,
+// so it is never sent to third-party image hosts. Redirects to other hosts
+// (such as signed storage URLs) have the header stripped by net/http.
+func (f *Fetcher) SetAuthToken(host, token string) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" || token == "" {
+		return
+	}
+	f.authTokens[host] = token
+}
+
+// authorize adds an Authorization header if the request targets a host
+// with a registered token
+func (f *Fetcher) authorize(req *http.Request) {
+	if req.URL.Scheme != "https" {
+		return
+	}
+	if token, ok := f.authTokens[strings.ToLower(req.URL.Hostname())]; ok {
+		req.Header.Set("Authorization", "token "+token)
+	}
+}
@@ -0,0 +1,80 @@
+package download
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestFetcher_SetAuthToken(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name      string
+		tokenHost string
+		want      string
+	}{
+		{"matching host", serverURL.Hostname(), "token secret"},
+		{"other host", "github.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAuth = ""
+			fetcher := NewFetcher(1024, 5*time.Second, 1)
+			fetcher.client = server.Client()
+			fetcher.SetAuthToken(tt.tokenHost, "secret")
+
+			result := fetcher.FetchSingle(context.Background(), server.URL+"/a.png")
+			if result.Error != nil {
+				t.Fatalf("FetchSingle failed: %v", result.Error)
+			}
+			if gotAuth != tt.want {
+				t.Errorf("Authorization = %q, want %q", gotAuth, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetcher_SetAuthToken_PlainHTTP(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	fetcher := NewFetcher(1024, 5*time.Second, 1)
+	fetcher.SetAuthToken(serverURL.Hostname(), "secret")
+
+	result := fetcher.FetchSingle(context.Background(), server.URL+"/a.png")
+	if result.Error != nil {
+		t.Fatalf("FetchSingle failed: %v", result.Error)
+	}
+	if gotAuth != "" {
+		t.Errorf("Token must not be sent over plain HTTP, got Authorization %q", gotAuth)
+	}
+}
+
+func TestFetcher_SetAuthToken_IgnoresEmpty(t *testing.T) {
+	fetcher := NewFetcher(1024, 5*time.Second, 1)
+	fetcher.SetAuthToken("", "secret")
+	fetcher.SetAuthToken("github.com", "")
+
+	if len(fetcher.authTokens) != 0 {
+		t.Errorf("Expected no registered tokens, got %v", fetcher.authTokens)
+	}
+}
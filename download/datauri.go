@@ -0,0 +1,126 @@
+package download
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// isDataURI reports whether rawURL is an inline data: URI
+func isDataURI(rawURL string) bool {
+	return len(rawURL) >= 5 && strings.EqualFold(rawURL[:5], "data:")
+}
+
+// fetchDataURI decodes an inline data: URI into a Result, applying the same
+// size and content-type checks as HTTP downloads
+func (f *Fetcher) fetchDataURI(rawURL string) Result {
+	result := Result{URL: rawURL}
+
+	// Format: data:[<mediatype>][;base64],<data>
+	comma := strings.Index(rawURL, ",")
+	if comma < 0 {
+		result.Error = newFetchError(CategoryInvalidURL, fmt.Errorf("malformed data URI: missing ',' separator"))
+		return result
+	}
+	header := rawURL[len("data:"):comma]
+	payload := rawURL[comma+1:]
+
+	params := strings.Split(header, ";")
+	contentType := strings.TrimSpace(params[0])
+	isBase64 := false
+	for _, param := range params[1:] {
+		if strings.EqualFold(strings.TrimSpace(param), "base64") {
+			isBase64 = true
+		}
+	}
+
+	if err := ValidateContentType(contentType); err != nil {
+		result.Error = newFetchError(CategoryContentType, err)
+		return result
+	}
+
+	// Reject oversized payloads before decoding them. Percent-encoding can
+	// triple the size of the payload, so only the base64 estimate is exact.
+	estimated := int64(len(payload))
+	if isBase64 {
+		estimated = estimated / 4 * 3
+	} else {
+		estimated = estimated / 3
+	}
+	if estimated > f.maxSize {
+		result.Error = newFetchError(CategoryTooLarge, fmt.Errorf("file too large: data URI payload of about %d bytes (max %d)", estimated, f.maxSize))
+		return result
+	}
+
+	var data []byte
+	if isBase64 {
+		decoded, err := decodeBase64Payload(payload)
+		if err != nil {
+			result.Error = newFetchError(CategoryInvalidURL, fmt.Errorf("malformed data URI: %w", err))
+			return result
+		}
+		data = decoded
+	} else {
+		decoded, err := url.PathUnescape(payload)
+		if err != nil {
+			result.Error = newFetchError(CategoryInvalidURL, fmt.Errorf("malformed data URI: %w", err))
+			return result
+		}
+		data = []byte(decoded)
+	}
+
+	if len(data) == 0 {
+		result.Error = newFetchError(CategoryInvalidURL, fmt.Errorf("malformed data URI: empty payload"))
+		return result
+	}
+	if int64(len(data)) > f.maxSize {
+		result.Error = newFetchError(CategoryTooLarge, fmt.Errorf("file too large: %d bytes (max %d)", len(data), f.maxSize))
+		return result
+	}
+
+	result.ContentType = contentType
+	result.Data = data
+	result.Size = int64(len(data))
+	return result
+}
+
+// decodeBase64Payload decodes a base64 data URI payload, tolerating
+// percent-encoding, embedded whitespace and missing padding
+func decodeBase64Payload(payload string) ([]byte, error) {
+	if strings.Contains(payload, "%") {
+		unescaped, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = unescaped
+	}
+	payload = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r':
+			return -1
+		}
+		return r
+	}, payload)
+
+	if data, err := base64.StdEncoding.DecodeString(payload); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+}
+
+// DisplayURL shortens data URIs for log output, which would otherwise dump
+// the whole payload; other URLs are returned unchanged
+func DisplayURL(rawURL string) string {
+	if !isDataURI(rawURL) {
+		return rawURL
+	}
+	header := rawURL
+	if comma := strings.Index(rawURL, ","); comma >= 0 {
+		header = rawURL[:comma]
+	}
+	if len(header) > 64 {
+		header = header[:64]
+	}
+	return fmt.Sprintf("%s,… (%d bytes inline)", header, len(rawURL))
+}
@@ -0,0 +1,94 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetcher_FetchSingle_DataURI(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	encoded := base64.StdEncoding.EncodeToString(png)
+
+	tests := []struct {
+		name     string
+		uri      string
+		wantType string
+		wantData []byte
+	}{
+		{"base64", "data:image/png;base64," + encoded, "image/png", png},
+		{"base64 unpadded", "data:image/png;base64," + strings.TrimRight(encoded, "="), "image/png", png},
+		{"base64 with whitespace", "data:image/png;base64," + encoded[:4] + "\n" + encoded[4:], "image/png", png},
+		{"base64 percent-encoded", "data:image/png;base64," + strings.ReplaceAll(encoded, "+", "%2B"), "image/png", png},
+		{"uppercase scheme", "DATA:image/png;base64," + encoded, "image/png", png},
+		{"percent-encoded svg", "data:image/svg+xml,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%2F%3E", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)},
+	}
+
+	fetcher := NewFetcher(1024, 5*time.Second, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fetcher.FetchSingle(context.Background(), tt.uri)
+			if result.Error != nil {
+				t.Fatalf("FetchSingle failed: %v", result.Error)
+			}
+			if result.ContentType != tt.wantType {
+				t.Errorf("ContentType = %q, want %q", result.ContentType, tt.wantType)
+			}
+			if !bytes.Equal(result.Data, tt.wantData) {
+				t.Errorf("Data = %q, want %q", result.Data, tt.wantData)
+			}
+			if result.Size != int64(len(tt.wantData)) {
+				t.Errorf("Size = %d, want %d", result.Size, len(tt.wantData))
+			}
+			if result.URL != tt.uri {
+				t.Errorf("URL should be the original data URI")
+			}
+		})
+	}
+}
+
+func TestFetcher_FetchSingle_DataURIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want ErrorCategory
+	}{
+		{"missing separator", "data:image/png;base64", CategoryInvalidURL},
+		{"invalid base64", "data:image/png;base64,!!!not-base64!!!", CategoryInvalidURL},
+		{"empty payload", "data:image/png;base64,", CategoryInvalidURL},
+		{"non-image type", "data:text/html;base64,PGh0bWw+", CategoryContentType},
+		{"missing type", "data:;base64,AAAA", CategoryContentType},
+		{"too large", "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, 2048)), CategoryTooLarge},
+	}
+
+	fetcher := NewFetcher(1024, 5*time.Second, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fetcher.FetchSingle(context.Background(), tt.uri)
+			if result.Error == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if got := CategorizeError(result.Error); got != tt.want {
+				t.Errorf("CategorizeError(%v) = %q, want %q", result.Error, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayURL(t *testing.T) {
+	if got := DisplayURL("https://example.com/a.png"); got != "https://example.com/a.png" {
+		t.Errorf("DisplayURL should not change HTTP URLs, got %q", got)
+	}
+
+	uri := "data:image/png;base64," + strings.Repeat("A", 4000)
+	got := DisplayURL(uri)
+	if len(got) > 120 {
+		t.Errorf("DisplayURL should shorten data URIs, got %d characters", len(got))
+	}
+	if !strings.HasPrefix(got, "data:image/png;base64,") {
+		t.Errorf("DisplayURL should keep the data URI header, got %q", got)
+	}
+}
@@ -0,0 +1,85 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrorCategory classifies why a download failed
+type ErrorCategory string
+
+const (
+	// CategoryInvalidURL means the URL could not be turned into a request
+	CategoryInvalidURL ErrorCategory = "invalid_url"
+	// CategoryNetwork means the connection failed or was interrupted
+	CategoryNetwork ErrorCategory = "network"
+	// CategoryTimeout means the request did not finish in time
+	CategoryTimeout ErrorCategory = "timeout"
+	// CategoryCanceled means the download was canceled before it finished
+	CategoryCanceled ErrorCategory = "canceled"
+	// CategoryHTTPStatus means the server answered with a non-200 status
+	CategoryHTTPStatus ErrorCategory = "http_status"
+	// CategoryContentType means the response was not an accepted image type
+	CategoryContentType ErrorCategory = "content_type"
+	// CategoryTooLarge means the image exceeded the size limit
+	CategoryTooLarge ErrorCategory = "too_large"
+	// CategoryUnknown is used for errors that carry no category
+	CategoryUnknown ErrorCategory = "unknown"
+)
+
+// FetchError is a download failure tagged with its category
+type FetchError struct {
+	Category   ErrorCategory
+	StatusCode int // HTTP status code, for CategoryHTTPStatus
+	Err        error
+}
+
+// Error implements the error interface
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// newFetchError wraps err with the given category
+func newFetchError(category ErrorCategory, err error) *FetchError {
+	return &FetchError{Category: category, Err: err}
+}
+
+// CategorizeError returns the category of a download error
+func CategorizeError(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+
+	var fetchErr *FetchError
+	if errors.As(err, &fetchErr) {
+		return fetchErr.Category
+	}
+
+	return categorizeTransportError(err)
+}
+
+// categorizeTransportError classifies errors returned by the HTTP client
+func categorizeTransportError(err error) ErrorCategory {
+	if errors.Is(err, context.Canceled) {
+		return CategoryCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CategoryTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return CategoryTimeout
+		}
+		return CategoryNetwork
+	}
+
+	return CategoryUnknown
+}
@@ -0,0 +1,76 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCategorizeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCategory
+	}{
+		{"nil", nil, ""},
+		{"fetch error", newFetchError(CategoryTooLarge, errors.New("too big")), CategoryTooLarge},
+		{"wrapped fetch error", fmt.Errorf("outer: %w", newFetchError(CategoryContentType, errors.New("bad"))), CategoryContentType},
+		{"canceled", context.Canceled, CategoryCanceled},
+		{"deadline", context.DeadlineExceeded, CategoryTimeout},
+		{"plain", errors.New("something"), CategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CategorizeError(tt.err); got != tt.want {
+				t.Errorf("CategorizeError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetcher_ErrorCategories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/big":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(make([]byte, 2048))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(1024, 5*time.Second, 1)
+	ctx := context.Background()
+
+	tests := []struct {
+		path string
+		want ErrorCategory
+	}{
+		{"/missing", CategoryHTTPStatus},
+		{"/html", CategoryContentType},
+		{"/big", CategoryTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := fetcher.FetchSingle(ctx, server.URL+tt.path)
+			if got := CategorizeError(result.Error); got != tt.want {
+				t.Errorf("CategorizeError(%v) = %q, want %q", result.Error, got, tt.want)
+			}
+		})
+	}
+
+	result := fetcher.FetchSingle(ctx, "http://[::1]:namedport/x.png")
+	if got := CategorizeError(result.Error); got != CategoryInvalidURL {
+		t.Errorf("CategorizeError(%v) = %q, want %q", result.Error, got, CategoryInvalidURL)
+	}
+}
@@ -8,14 +8,20 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/kojikawamura/gh-ccimg/httpcache"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/ratelimit"
 )
 
 // Result represents the result of downloading a single URL
 type Result struct {
+	Index       int // Position of the URL in the input slice
 	URL         string
 	Data        []byte
 	ContentType string
 	Size        int64
+	Ref         markdown.ImageRef // Where the image was referenced, if known
 	Error       error
 }
 
@@ -28,11 +34,13 @@ type Fetcher struct {
 	reporter    Reporter
 	maxRetries  int
 	baseDelay   time.Duration
+	authTokens  map[string]string // host -> token, see SetAuthToken
+	governor    *ratelimit.Governor
 }
 
 // NewFetcher creates a new fetcher with the specified limits
 func NewFetcher(maxSize int64, timeout time.Duration, concurrency int) *Fetcher {
-	return &Fetcher{
+	f := &Fetcher{
 		client: &http.Client{
 			Timeout: timeout,
 		},
@@ -42,7 +50,15 @@ func NewFetcher(maxSize int64, timeout time.Duration, concurrency int) *Fetcher
 		reporter:    NewNoOpReporter(), // Default to no-op
 		maxRetries:  3,                  // Default 3 retries
 		baseDelay:   500 * time.Millisecond, // Default 500ms base delay
+		authTokens:  make(map[string]string),
+		governor:    ratelimit.New(ratelimit.DefaultMaxWait),
 	}
+	f.governor.SetObserver(func(host string, wait time.Duration) {
+		if reporter, ok := f.reporter.(RateLimitReporter); ok {
+			reporter.RateLimited(host, wait)
+		}
+	})
+	return f
 }
 
 // SetReporter sets the progress reporter
@@ -50,7 +66,27 @@ func (f *Fetcher) SetReporter(reporter Reporter) {
 	f.reporter = reporter
 }
 
-// FetchConcurrent downloads multiple URLs concurrently
+// SetGovernor shares a rate-limit governor with other fetchers and API
+// clients. The governor's observer is left to the caller.
+func (f *Fetcher) SetGovernor(governor *ratelimit.Governor) {
+	f.governor = governor
+}
+
+// SetCache revalidates image downloads against cache with conditional
+// requests, so unchanged images are not transferred again
+func (f *Fetcher) SetCache(cache *httpcache.Cache) {
+	f.client.Transport = cache.Transport(f.client.Transport)
+}
+
+// job is a single unit of work handed to a worker
+type job struct {
+	index int
+	url   string
+}
+
+// FetchConcurrent downloads multiple URLs concurrently.
+// Results are returned in the same order as the input URLs, regardless of
+// the order in which the downloads complete.
 func (f *Fetcher) FetchConcurrent(ctx context.Context, urls []string) []Result {
 	if len(urls) == 0 {
 		return []Result{}
@@ -60,21 +96,21 @@ func (f *Fetcher) FetchConcurrent(ctx context.Context, urls []string) []Result {
 	defer f.reporter.Finish()
 
 	// Create channels for work distribution
-	urlChan := make(chan string, len(urls))
+	jobChan := make(chan job, len(urls))
 	resultChan := make(chan Result, len(urls))
 
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < f.concurrency; i++ {
 		wg.Add(1)
-		go f.worker(ctx, &wg, urlChan, resultChan)
+		go f.worker(ctx, &wg, jobChan, resultChan)
 	}
 
 	// Send URLs to workers
-	for _, url := range urls {
-		urlChan <- url
+	for i, url := range urls {
+		jobChan <- job{index: i, url: url}
 	}
-	close(urlChan)
+	close(jobChan)
 
 	// Wait for workers to finish
 	go func() {
@@ -82,32 +118,67 @@ func (f *Fetcher) FetchConcurrent(ctx context.Context, urls []string) []Result {
 		close(resultChan)
 	}()
 
-	// Collect results
-	var results []Result
+	// Collect results into their original slots so ordering is deterministic
+	results := make([]Result, len(urls))
+	received := make([]bool, len(urls))
 	completed := 0
 	for result := range resultChan {
-		results = append(results, result)
+		results[result.Index] = result
+		received[result.Index] = true
 		completed++
 		f.reporter.Update(completed, result.URL, result.Error == nil, result.Error)
 	}
 
+	// Jobs left unprocessed after cancellation still get a result
+	for i, ok := range received {
+		if !ok {
+			results[i] = Result{Index: i, URL: urls[i], Error: ctx.Err()}
+			if results[i].Error == nil {
+				results[i].Error = fmt.Errorf("download was not attempted")
+			}
+		}
+	}
+
+	return results
+}
+
+// FetchImages downloads the images behind the given references concurrently.
+// Each result carries the reference it was downloaded for, in input order.
+// A reference's DownloadURL is fetched in place of its URL when set, but
+// results always report the original URL.
+func (f *Fetcher) FetchImages(ctx context.Context, refs []markdown.ImageRef) []Result {
+	urls := make([]string, len(refs))
+	for i, ref := range refs {
+		urls[i] = ref.URL
+		if ref.DownloadURL != "" {
+			urls[i] = ref.DownloadURL
+		}
+	}
+
+	results := f.FetchConcurrent(ctx, urls)
+	for i := range results {
+		results[i].URL = refs[i].URL
+		results[i].Ref = refs[i]
+	}
 	return results
 }
 
 // worker is a worker goroutine that processes URLs from the channel
-func (f *Fetcher) worker(ctx context.Context, wg *sync.WaitGroup, urlChan <-chan string, resultChan chan<- Result) {
+func (f *Fetcher) worker(ctx context.Context, wg *sync.WaitGroup, jobChan <-chan job, resultChan chan<- Result) {
 	defer wg.Done()
 
-	for url := range urlChan {
+	for j := range jobChan {
 		select {
 		case <-ctx.Done():
 			resultChan <- Result{
-				URL:   url,
+				Index: j.index,
+				URL:   j.url,
 				Error: ctx.Err(),
 			}
 			return
 		default:
-			result := f.fetchSingle(ctx, url)
+			result := f.fetchSingle(ctx, j.url)
+			result.Index = j.index
 			resultChan <- result
 		}
 	}
@@ -115,6 +186,11 @@ func (f *Fetcher) worker(ctx context.Context, wg *sync.WaitGroup, urlChan <-chan
 
 // fetchSingle downloads a single URL with size and content-type validation and retry logic
 func (f *Fetcher) fetchSingle(ctx context.Context, url string) Result {
+	// Inline images are decoded locally rather than requested over HTTP
+	if isDataURI(url) {
+		return f.fetchDataURI(url)
+	}
+
 	result := Result{URL: url}
 
 	// Retry loop with exponential backoff
@@ -122,12 +198,19 @@ func (f *Fetcher) fetchSingle(ctx context.Context, url string) Result {
 		// Create request with context
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			result.Error = fmt.Errorf("failed to create request: %w", err)
+			result.Error = newFetchError(CategoryInvalidURL, fmt.Errorf("failed to create request: %w", err))
 			return result // Don't retry on request creation errors
 		}
 
 		// Set user agent
 		req.Header.Set("User-Agent", "gh-ccimg/1.0")
+		f.authorize(req)
+
+		// Hold back while the host is rate limited for any worker
+		if err := f.governor.Wait(ctx, req.URL.Host); err != nil {
+			result.Error = newFetchError(categorizeTransportError(err), err)
+			return result
+		}
 
 		// Perform request
 		resp, err := f.client.Do(req)
@@ -137,27 +220,41 @@ func (f *Fetcher) fetchSingle(ctx context.Context, url string) Result {
 				time.Sleep(delay)
 				continue
 			}
-			result.Error = fmt.Errorf("HTTP request failed after %d attempts: %w", attempt+1, err)
+			category := categorizeTransportError(err)
+			if category == CategoryUnknown {
+				category = CategoryNetwork
+			}
+			result.Error = newFetchError(category, fmt.Errorf("HTTP request failed after %d attempts: %w", attempt+1, err))
 			return result
 		}
 		defer resp.Body.Close()
 
 		// Check HTTP status
 		if resp.StatusCode != http.StatusOK {
-			if attempt < f.maxRetries && f.isRetryableStatusCode(resp.StatusCode) {
+			// Rate limits pause every worker until the host is ready again
+			if wait, limited := f.rateLimitWait(resp, attempt); limited && attempt < f.maxRetries {
+				resp.Body.Close()
+				if f.governor.Pause(req.URL.Host, wait) {
+					continue
+				}
+			} else if attempt < f.maxRetries && f.isRetryableStatusCode(resp.StatusCode) {
 				resp.Body.Close()
 				delay := f.calculateBackoffDelay(attempt)
 				time.Sleep(delay)
 				continue
 			}
-			result.Error = fmt.Errorf("HTTP %d: %s (after %d attempts)", resp.StatusCode, resp.Status, attempt+1)
+			result.Error = &FetchError{
+				Category:   CategoryHTTPStatus,
+				StatusCode: resp.StatusCode,
+				Err:        fmt.Errorf("HTTP %d: %s (after %d attempts)", resp.StatusCode, resp.Status, attempt+1),
+			}
 			return result
 		}
 
 		// Get and validate content type
 		contentType := resp.Header.Get("Content-Type")
 		if err := ValidateContentType(contentType); err != nil {
-			result.Error = err
+			result.Error = newFetchError(CategoryContentType, err)
 			return result // Don't retry on content type validation errors
 		}
 		result.ContentType = contentType
@@ -165,7 +262,7 @@ func (f *Fetcher) fetchSingle(ctx context.Context, url string) Result {
 		// Check content length if available
 		if resp.ContentLength > 0 {
 			if resp.ContentLength > f.maxSize {
-				result.Error = fmt.Errorf("file too large: %d bytes (max %d)", resp.ContentLength, f.maxSize)
+				result.Error = newFetchError(CategoryTooLarge, fmt.Errorf("file too large: %d bytes (max %d)", resp.ContentLength, f.maxSize))
 				return result // Don't retry on size validation errors
 			}
 		}
@@ -184,13 +281,13 @@ func (f *Fetcher) fetchSingle(ctx context.Context, url string) Result {
 				time.Sleep(delay)
 				continue
 			}
-			result.Error = fmt.Errorf("failed to read response body after %d attempts: %w", attempt+1, err)
+			result.Error = newFetchError(CategoryNetwork, fmt.Errorf("failed to read response body after %d attempts: %w", attempt+1, err))
 			return result
 		}
 
 		// Check if we exceeded size limit
 		if int64(len(data)) > f.maxSize {
-			result.Error = fmt.Errorf("file too large: %d bytes (max %d)", len(data), f.maxSize)
+			result.Error = newFetchError(CategoryTooLarge, fmt.Errorf("file too large: %d bytes (max %d)", len(data), f.maxSize))
 			return result // Don't retry on size validation errors
 		}
 
@@ -251,6 +348,24 @@ func (f *Fetcher) isRetryableStatusCode(statusCode int) bool {
 	}
 }
 
+// rateLimitWait reports whether resp was rejected by a rate limit and how
+// long to wait before retrying. 429 responses without rate-limit headers
+// fall back to the exponential backoff.
+func (f *Fetcher) rateLimitWait(resp *http.Response, attempt int) (time.Duration, bool) {
+	wait, ok := ratelimit.Delay(resp.Header, time.Now())
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		if !ok {
+			wait = f.calculateBackoffDelay(attempt)
+		}
+		return wait, true
+	case http.StatusForbidden:
+		return wait, ok
+	default:
+		return 0, false
+	}
+}
+
 // calculateBackoffDelay calculates exponential backoff delay
 func (f *Fetcher) calculateBackoffDelay(attempt int) time.Duration {
 	// Exponential backoff: base_delay * 2^attempt with jitter
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
 )
 
 func TestNewFetcher(t *testing.T) {
@@ -137,6 +139,69 @@ func TestFetcher_FetchSingle_HTTPError(t *testing.T) {
 	}
 }
 
+// rateLimitReporter records rate-limit pauses reported by the fetcher
+type rateLimitReporter struct {
+	NoOpReporter
+	waits []time.Duration
+}
+
+func (r *rateLimitReporter) RateLimited(host string, wait time.Duration) {
+	r.waits = append(r.waits, wait)
+}
+
+func TestFetcher_FetchSingle_RateLimited(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(1024*1024, 30*time.Second, 5)
+	reporter := &rateLimitReporter{}
+	fetcher.SetReporter(reporter)
+
+	start := time.Now()
+	result := fetcher.FetchSingle(context.Background(), server.URL)
+	if result.Error != nil {
+		t.Fatalf("FetchSingle failed: %v", result.Error)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Retried after %v, want to honor Retry-After of 1s", elapsed)
+	}
+	if calls != 2 {
+		t.Errorf("Server called %d times, want 2", calls)
+	}
+	if len(reporter.waits) != 1 || reporter.waits[0] != time.Second {
+		t.Errorf("Reported waits = %v, want [1s]", reporter.waits)
+	}
+}
+
+func TestFetcher_FetchSingle_RateLimitTooLong(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(1024*1024, 30*time.Second, 5)
+	result := fetcher.FetchSingle(context.Background(), server.URL)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "HTTP 429") {
+		t.Errorf("Error = %v, want HTTP 429", result.Error)
+	}
+	if calls != 1 {
+		t.Errorf("Server called %d times, want 1", calls)
+	}
+}
+
 func TestFetcher_FetchSingle_Timeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Simulate slow response
@@ -214,6 +279,100 @@ func TestFetcher_FetchConcurrent(t *testing.T) {
 	}
 }
 
+func TestFetcher_FetchConcurrent_PreservesOrder(t *testing.T) {
+	// Earlier paths respond more slowly so completion order is reversed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/%d", &n)
+		time.Sleep(time.Duration(5-n) * 20 * time.Millisecond)
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf("data-%d", n)))
+	}))
+	defer server.Close()
+
+	var urls []string
+	for i := 0; i < 5; i++ {
+		urls = append(urls, fmt.Sprintf("%s/%d", server.URL, i))
+	}
+
+	fetcher := NewFetcher(1024*1024, 30*time.Second, 5)
+	results := fetcher.FetchConcurrent(context.Background(), urls)
+
+	if len(results) != len(urls) {
+		t.Fatalf("Expected %d results, got %d", len(urls), len(results))
+	}
+
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("Result %d Index = %d, want %d", i, result.Index, i)
+		}
+		if result.URL != urls[i] {
+			t.Errorf("Result %d URL = %q, want %q", i, result.URL, urls[i])
+		}
+		if want := fmt.Sprintf("data-%d", i); string(result.Data) != want {
+			t.Errorf("Result %d Data = %q, want %q", i, result.Data, want)
+		}
+	}
+}
+
+func TestFetcher_FetchImages_CarriesRef(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	refs := []markdown.ImageRef{
+		{URL: server.URL + "/a.png", Alt: "first", Source: markdown.Source{Kind: markdown.SourceIssueBody}},
+		{URL: server.URL + "/b.png", Alt: "second", Source: markdown.Source{Kind: markdown.SourceComment, CommentID: 9}},
+	}
+
+	fetcher := NewFetcher(1024*1024, 30*time.Second, 2)
+	results := fetcher.FetchImages(context.Background(), refs)
+
+	if len(results) != len(refs) {
+		t.Fatalf("Expected %d results, got %d", len(refs), len(results))
+	}
+	for i, result := range results {
+		if result.Error != nil {
+			t.Errorf("Result %d failed: %v", i, result.Error)
+		}
+		if result.Ref != refs[i] {
+			t.Errorf("Result %d Ref = %+v, want %+v", i, result.Ref, refs[i])
+		}
+	}
+}
+
+func TestFetcher_FetchImages_DownloadURL(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	ref := markdown.ImageRef{
+		URL:         "https://github.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666",
+		DownloadURL: server.URL + "/signed.png?jwt=abc",
+	}
+
+	fetcher := NewFetcher(1024*1024, 30*time.Second, 1)
+	results := fetcher.FetchImages(context.Background(), []markdown.ImageRef{ref})
+
+	if results[0].Error != nil {
+		t.Fatalf("FetchImages failed: %v", results[0].Error)
+	}
+	if requested != "/signed.png?jwt=abc" {
+		t.Errorf("Requested %q, want the signed download URL", requested)
+	}
+	if results[0].URL != ref.URL {
+		t.Errorf("Result URL = %q, want original %q", results[0].URL, ref.URL)
+	}
+}
+
 func TestFetcher_FetchConcurrent_EmptyURLs(t *testing.T) {
 	fetcher := NewFetcher(1024*1024, 30*time.Second, 5)
 	ctx := context.Background()
@@ -13,6 +13,13 @@ type Reporter interface {
 	Finish()
 }
 
+// RateLimitReporter is implemented by reporters that announce rate-limit
+// pauses. The fetcher checks for it on its reporter.
+type RateLimitReporter interface {
+	// RateLimited is called when downloads from host pause for wait
+	RateLimited(host string, wait time.Duration)
+}
+
 // ConsoleReporter implements console-based progress reporting
 type ConsoleReporter struct {
 	writer  io.Writer
@@ -45,9 +52,9 @@ func (r *ConsoleReporter) Start(total int) {
 func (r *ConsoleReporter) Update(completed int, url string, success bool, err error) {
 	if r.verbose {
 		if success {
-			fmt.Fprintf(r.writer, "✓ [%d/%d] Downloaded: %s\n", completed, r.total, url)
+			fmt.Fprintf(r.writer, "✓ [%d/%d] Downloaded: %s\n", completed, r.total, DisplayURL(url))
 		} else {
-			fmt.Fprintf(r.writer, "✗ [%d/%d] Failed: %s - %v\n", completed, r.total, url, err)
+			fmt.Fprintf(r.writer, "✗ [%d/%d] Failed: %s - %v\n", completed, r.total, DisplayURL(url), err)
 		}
 	} else {
 		// Simple progress for non-verbose mode
@@ -57,6 +64,11 @@ func (r *ConsoleReporter) Update(completed int, url string, success bool, err er
 	}
 }
 
+// RateLimited reports that downloads from a host are paused
+func (r *ConsoleReporter) RateLimited(host string, wait time.Duration) {
+	fmt.Fprintf(r.writer, "Rate limited by %s, pausing downloads for %v\n", host, wait.Round(time.Second))
+}
+
 // Finish completes the progress reporting
 func (r *ConsoleReporter) Finish() {
 	duration := time.Since(r.start)
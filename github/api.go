@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// API performs requests against the GitHub REST and GraphQL APIs. The
+// default implementation is built on the go-gh clients; tests can supply
+// their own or point the default one at an httptest server via BaseURL.
+type API interface {
+	// Request performs a REST request. path is relative to the API root or
+	// an absolute URL, as found in Link headers. Non-2xx responses are
+	// returned as errors.
+	Request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error)
+	// GraphQL runs a query and decodes its data into response
+	GraphQL(ctx context.Context, query string, variables map[string]interface{}, response interface{}) error
+}
+
+// ghAPI implements API with the go-gh REST and GraphQL clients
+type ghAPI struct {
+	rest    *api.RESTClient
+	graphql *api.GraphQLClient
+}
+
+// newGHAPI creates the go-gh backed API for the given options
+func newGHAPI(opts ClientOptions) (*ghAPI, error) {
+	ghOpts := api.ClientOptions{
+		Host:         opts.Host,
+		AuthToken:    opts.AuthToken,
+		Timeout:      opts.Timeout,
+		Headers:      map[string]string{"Accept": fullMediaType},
+		LogIgnoreEnv: true,
+	}
+	var transport http.RoundTripper
+	if opts.BaseURL != "" {
+		base, err := url.Parse(strings.TrimSuffix(opts.BaseURL, "/"))
+		if err != nil {
+			return nil, err
+		}
+		transport = &baseURLTransport{base: base, next: http.DefaultTransport}
+	}
+	if opts.Cache != nil {
+		// Wrapped outside the base URL rewrite so entries are keyed by the
+		// host's own API URLs
+		transport = opts.Cache.Transport(transport)
+	}
+	if transport != nil {
+		// go-gh skips its own token lookup when a transport is supplied
+		if ghOpts.AuthToken == "" {
+			ghOpts.AuthToken = TokenForHost(opts.Host)
+		}
+		ghOpts.Transport = transport
+	}
+
+	rest, err := api.NewRESTClient(ghOpts)
+	if err != nil {
+		return nil, err
+	}
+	graphql, err := api.NewGraphQLClient(ghOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &ghAPI{rest: rest, graphql: graphql}, nil
+}
+
+// Request implements API
+func (g *ghAPI) Request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return g.rest.RequestWithContext(ctx, method, path, body)
+}
+
+// GraphQL implements API
+func (g *ghAPI) GraphQL(ctx context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return g.graphql.DoWithContext(ctx, query, variables, response)
+}
+
+// baseURLTransport sends API requests to a fixed base URL instead of the
+// host's API endpoint. REST paths keep their path below the API root and
+// GraphQL requests go to BASE/graphql.
+type baseURLTransport struct {
+	base *url.URL
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.base.Host {
+		return t.next.RoundTrip(req)
+	}
+
+	path := req.URL.Path
+	switch {
+	case path == "/api/graphql":
+		path = "/graphql"
+	case strings.HasPrefix(path, "/api/v3/"):
+		path = strings.TrimPrefix(path, "/api/v3")
+	}
+
+	rewritten := req.Clone(req.Context())
+	rewritten.URL.Scheme = t.base.Scheme
+	rewritten.URL.Host = t.base.Host
+	rewritten.URL.Path = t.base.Path + path
+	rewritten.URL.RawPath = ""
+	rewritten.Host = t.base.Host
+	return t.next.RoundTrip(rewritten)
+}
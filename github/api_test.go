@@ -0,0 +1,266 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kojikawamura/gh-ccimg/ratelimit"
+)
+
+// newTestClient returns a client that sends every request to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClientWithOptions(ClientOptions{
+		Timeout:   5 * time.Second,
+		AuthToken: "test-token",
+		BaseURL:   server.URL,
+	})
+	client.baseDelay = time.Millisecond
+	return client
+}
+
+func TestClient_FetchIssue_HTTP(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octo/repo/issues/12" {
+			t.Errorf("Path = %q, want /repos/octo/repo/issues/12", r.URL.Path)
+		}
+		if got := r.Header.Get("Accept"); got != fullMediaType {
+			t.Errorf("Accept = %q, want %q", got, fullMediaType)
+		}
+		if got := r.Header.Get("Authorization"); got != "token test-token" {
+			t.Errorf("Authorization = %q, want token test-token", got)
+		}
+		w.Write([]byte(`{"number": 12, "title": "Broken layout", "body": "![x](https://example.com/x.png)", "user": {"login": "octocat"}}`))
+	})
+
+	issue, err := client.FetchIssue("octo", "repo", "12")
+	if err != nil {
+		t.Fatalf("FetchIssue failed: %v", err)
+	}
+	if issue.Number != 12 || issue.User.Login != "octocat" {
+		t.Errorf("FetchIssue = %+v", issue)
+	}
+}
+
+func TestClient_FetchComments_Paginates(t *testing.T) {
+	var serverURL string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("per_page") != strconv.Itoa(perPage) {
+			t.Errorf("per_page = %q, want %d", r.URL.Query().Get("per_page"), perPage)
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/octo/repo/issues/1/comments?per_page=%d&page=2>; rel="next", <%s/repos/octo/repo/issues/1/comments?per_page=%d&page=2>; rel="last"`, serverURL, perPage, serverURL, perPage))
+			w.Write([]byte(`[{"id": 1, "body": "one"}, {"id": 2, "body": "two"}]`))
+		case "2":
+			w.Write([]byte(`[{"id": 3, "body": "three"}]`))
+		default:
+			t.Errorf("Unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+	serverURL = client.opts.BaseURL
+
+	comments, err := client.FetchComments("octo", "repo", "1")
+	if err != nil {
+		t.Fatalf("FetchComments failed: %v", err)
+	}
+	if len(comments) != 3 || comments[2].ID != 3 || comments[2].Kind != CommentKindIssue {
+		t.Errorf("FetchComments = %d comments, want 3 across two pages", len(comments))
+	}
+}
+
+func TestClient_HTTPErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		wantMessage string
+	}{
+		{"not found", http.StatusNotFound, "issue/PR 9 not found in octo/repo"},
+		{"unauthorized", http.StatusUnauthorized, "authentication failed. Please run 'gh auth login'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"message": "nope"}`))
+			})
+
+			_, err := client.FetchIssue("octo", "repo", "9")
+			if err == nil || err.Error() != tt.wantMessage {
+				t.Fatalf("FetchIssue error = %v, want %q", err, tt.wantMessage)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("FetchIssue error = %#v, want *APIError with status %d", err, tt.status)
+			}
+			if calls != 1 {
+				t.Errorf("Server called %d times, want 1 (no retries)", calls)
+			}
+		})
+	}
+}
+
+func TestClient_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"number": 1}`))
+	})
+
+	if _, err := client.FetchIssue("octo", "repo", "1"); err != nil {
+		t.Fatalf("FetchIssue failed: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Server called %d times, want 3", calls)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	t.Run("retries after reset", func(t *testing.T) {
+		var calls int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+				return
+			}
+			w.Write([]byte(`{"number": 1}`))
+		})
+
+		if _, err := client.FetchIssue("octo", "repo", "1"); err != nil {
+			t.Fatalf("FetchIssue failed: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("Server called %d times, want 2", calls)
+		}
+	})
+
+	t.Run("pauses the API host", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+			w.Write([]byte(`{"number": 1}`))
+		})
+		governor := ratelimit.New(ratelimit.DefaultMaxWait)
+		var paused []string
+		governor.SetObserver(func(host string, wait time.Duration) {
+			paused = append(paused, host)
+		})
+		client.SetGovernor(governor)
+
+		if _, err := client.FetchIssue("octo", "repo", "1"); err != nil {
+			t.Fatalf("FetchIssue failed: %v", err)
+		}
+		if len(paused) != 1 || paused[0] != "api.github.com" {
+			t.Errorf("Paused hosts = %v, want api.github.com only", paused)
+		}
+	})
+
+	t.Run("gives up on long waits", func(t *testing.T) {
+		var calls int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		_, err := client.FetchIssue("octo", "repo", "1")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("FetchIssue error = %v, want *APIError", err)
+		}
+		if !apiErr.RateLimited() || apiErr.RateLimit.RetryAfter != time.Hour || apiErr.RateLimit.Limit != 5000 {
+			t.Errorf("APIError = %+v, want rate limited with Retry-After 1h", apiErr)
+		}
+		if calls != 1 {
+			t.Errorf("Server called %d times, want 1", calls)
+		}
+	})
+}
+
+func TestBaseURLTransport(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+	}))
+	defer server.Close()
+
+	base, _ := url.Parse(server.URL + "/prefix")
+	transport := &baseURLTransport{base: base, next: http.DefaultTransport}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.github.com/repos/o/r/issues/1", "/prefix/repos/o/r/issues/1"},
+		{"https://ghe.example.com/api/v3/repos/o/r/issues/1?page=2", "/prefix/repos/o/r/issues/1?page=2"},
+		{"https://ghe.example.com/api/graphql", "/prefix/graphql"},
+		{"https://api.github.com/graphql", "/prefix/graphql"},
+		{server.URL + "/other?page=3", "/other?page=3"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip(%s) failed: %v", tt.url, err)
+		}
+		resp.Body.Close()
+		if gotPath != tt.want {
+			t.Errorf("RoundTrip(%s) reached %q, want %q", tt.url, gotPath, tt.want)
+		}
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestWithPerPage(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"repos/o/r/issues/1/comments", "repos/o/r/issues/1/comments?per_page=100"},
+		{"search/issues?q=x", "search/issues?q=x&per_page=100"},
+		{"search/issues?q=x&per_page=10", "search/issues?q=x&per_page=10"},
+	}
+
+	for _, tt := range tests {
+		if got := withPerPage(tt.path); got != tt.want {
+			t.Errorf("withPerPage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
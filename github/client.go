@@ -1,43 +1,178 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os/exec"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/kojikawamura/gh-ccimg/httpcache"
+	"github.com/kojikawamura/gh-ccimg/ratelimit"
 )
 
+// User represents the GitHub account that authored an issue or comment
+type User struct {
+	Login string `json:"login"`
+}
+
 // Issue represents a GitHub issue or pull request
 type Issue struct {
-	Number int    `json:"number"`
-	Title  string `json:"title"`
-	Body   string `json:"body"`
-	State  string `json:"state"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	BodyHTML  string    `json:"body_html"`
+	State     string    `json:"state"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// AuthorAssociation is the author's relationship to the repository,
+	// such as OWNER, MEMBER, CONTRIBUTOR or NONE
+	AuthorAssociation string `json:"author_association"`
+
+	// PullRequest is only present when the issue is a pull request
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request,omitempty"`
 }
 
+// IsPullRequest reports whether the issue is a pull request
+func (i *Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+// CommentKind distinguishes the places a comment can live on an issue or PR
+type CommentKind string
+
+const (
+	// CommentKindIssue is a conversation comment on an issue or PR
+	CommentKindIssue CommentKind = "issue"
+	// CommentKindReview is the summary body of a pull request review
+	CommentKindReview CommentKind = "review"
+	// CommentKindReviewComment is an inline review comment on a diff line
+	CommentKindReviewComment CommentKind = "review_comment"
+	// CommentKindDiscussion is a top-level comment on a discussion
+	CommentKindDiscussion CommentKind = "discussion_comment"
+	// CommentKindDiscussionReply is a threaded reply to a discussion comment
+	CommentKindDiscussionReply CommentKind = "discussion_reply"
+)
+
 // Comment represents a GitHub issue/PR comment
 type Comment struct {
-	ID        int       `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int         `json:"id"`
+	Kind      CommentKind `json:"-"`
+	Body      string      `json:"body"`
+	BodyHTML  string      `json:"body_html"`
+	User      User        `json:"user"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// AuthorAssociation is the author's relationship to the repository,
+	// such as OWNER, MEMBER, CONTRIBUTOR or NONE
+	AuthorAssociation string `json:"author_association"`
+
+	// Path and Line locate inline review comments in the diff
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// DefaultHost is the hostname of github.com
+const DefaultHost = "github.com"
+
+// fullMediaType asks the API to return rendered body_html alongside the raw
+// markdown body. The rendered HTML carries short-lived signed URLs for
+// attachments in private repositories.
+const fullMediaType = "application/vnd.github.full+json"
+
+// perPage is the page size requested from paginated REST endpoints
+const perPage = 100
+
+// authErrorMessage is returned when no usable credentials are available
+const authErrorMessage = "authentication failed. Please run 'gh auth login'"
+
+// ClientOptions configures a Client
+type ClientOptions struct {
+	// Host is the GitHub host, such as github.com or a GitHub Enterprise
+	// Server hostname. Defaults to github.com.
+	Host string
+	// Timeout bounds each API request
+	Timeout time.Duration
+	// AuthToken overrides the token gh has stored for Host
+	AuthToken string
+	// BaseURL sends all requests to this URL instead of the host's API
+	// endpoint, for example an httptest server
+	BaseURL string
+	// Cache revalidates REST responses with conditional requests when set.
+	// GraphQL queries are POSTs and cannot be revalidated, so FetchThread
+	// stores its result and checks it against the issue's REST endpoint.
+	Cache *httpcache.Cache
+	// API replaces the go-gh backed implementation entirely
+	API API
 }
 
-// Client handles GitHub API interactions via gh CLI
+// Client handles GitHub REST and GraphQL API interactions
 type Client struct {
+	host       string
 	timeout    time.Duration
 	maxRetries int
 	baseDelay  time.Duration
+	governor   *ratelimit.Governor
+
+	opts    ClientOptions
+	apiOnce sync.Once
+	api     API
+	apiErr  error
 }
 
-// NewClient creates a new GitHub client
+// NewClient creates a new GitHub client for github.com
 func NewClient(timeout time.Duration) *Client {
+	return NewClientForHost(DefaultHost, timeout)
+}
+
+// NewClientForHost creates a new GitHub client for the given host, such as a
+// GitHub Enterprise Server instance. Authentication uses gh's per-host login.
+func NewClientForHost(host string, timeout time.Duration) *Client {
+	return NewClientWithOptions(ClientOptions{Host: host, Timeout: timeout})
+}
+
+// NewClientWithOptions creates a new GitHub client from explicit options
+func NewClientWithOptions(opts ClientOptions) *Client {
+	if opts.Host == "" {
+		opts.Host = DefaultHost
+	}
 	return &Client{
-		timeout:    timeout,
+		host:       opts.Host,
+		timeout:    opts.Timeout,
 		maxRetries: 3,                        // Default 3 retries
 		baseDelay:  1 * time.Second,          // Default 1s base delay for GitHub API
+		governor:   ratelimit.New(ratelimit.DefaultMaxWait),
+		opts:       opts,
+		api:        opts.API,
+	}
+}
+
+// apiHost returns the host API requests are rate limited under. github.com
+// serves its API from api.github.com, whose limits are separate from those
+// of github.com downloads; Enterprise Server serves both from the instance.
+func apiHost(host string) string {
+	if host == DefaultHost {
+		return "api." + DefaultHost
 	}
+	return host
+}
+
+// SetGovernor shares a rate-limit governor with other clients and the image
+// fetcher, so a limit hit by one pauses them all
+func (c *Client) SetGovernor(governor *ratelimit.Governor) {
+	c.governor = governor
 }
 
 // FetchIssue retrieves an issue or pull request from GitHub with retry logic
@@ -47,53 +182,17 @@ func (c *Client) FetchIssue(owner, repo, num string) (*Issue, error) {
 	}
 
 	apiPath := fmt.Sprintf("repos/%s/%s/issues/%s", owner, repo, num)
-	
-	// Retry loop with exponential backoff
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		cmd := exec.Command("gh", "api", apiPath)
-		
-		output, err := cmd.Output()
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				stderr := string(exitErr.Stderr)
-				
-				// Don't retry on authentication or not found errors
-				if strings.Contains(stderr, "Not Found") || strings.Contains(stderr, "404") {
-					return nil, fmt.Errorf("issue/PR %s not found in %s/%s", num, owner, repo)
-				}
-				if strings.Contains(stderr, "Bad credentials") || strings.Contains(stderr, "401") {
-					return nil, fmt.Errorf("authentication failed. Please run 'gh auth login'")
-				}
-				
-				// Retry on rate limiting or server errors
-				if attempt < c.maxRetries && c.isRetryableGitHubError(stderr) {
-					delay := c.calculateBackoffDelay(attempt)
-					time.Sleep(delay)
-					continue
-				}
-				
-				return nil, fmt.Errorf("GitHub API error after %d attempts: %s", attempt+1, stderr)
-			}
-			
-			// Retry on general execution errors
-			if attempt < c.maxRetries {
-				delay := c.calculateBackoffDelay(attempt)
-				time.Sleep(delay)
-				continue
-			}
-			
-			return nil, fmt.Errorf("failed to execute gh command after %d attempts: %w", attempt+1, err)
-		}
-
-		var issue Issue
-		if err := json.Unmarshal(output, &issue); err != nil {
-			return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
-		}
+	output, err := c.get(apiPath, false, fmt.Sprintf("issue/PR %s not found in %s/%s", num, owner, repo))
+	if err != nil {
+		return nil, err
+	}
 
-		return &issue, nil
+	var issue Issue
+	if err := json.Unmarshal(output, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
 	}
 
-	return nil, fmt.Errorf("unexpected error in retry loop")
+	return &issue, nil
 }
 
 // FetchComments retrieves all comments for an issue or pull request with retry logic
@@ -103,53 +202,244 @@ func (c *Client) FetchComments(owner, repo, num string) ([]*Comment, error) {
 	}
 
 	apiPath := fmt.Sprintf("repos/%s/%s/issues/%s/comments", owner, repo, num)
-	
+	output, err := c.get(apiPath, true, fmt.Sprintf("issue/PR %s not found in %s/%s", num, owner, repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []*Comment
+	if err := json.Unmarshal(output, &comments); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+	for _, comment := range comments {
+		comment.Kind = CommentKindIssue
+	}
+
+	return comments, nil
+}
+
+// FetchComment retrieves a single issue or pull request conversation comment by ID
+func (c *Client) FetchComment(owner, repo string, id int) (*Comment, error) {
+	if owner == "" || repo == "" || id <= 0 {
+		return nil, fmt.Errorf("owner, repo, and comment ID are required")
+	}
+
+	apiPath := fmt.Sprintf("repos/%s/%s/issues/comments/%d", owner, repo, id)
+	output, err := c.get(apiPath, false, fmt.Sprintf("comment %d not found in %s/%s", id, owner, repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var comment Comment
+	if err := json.Unmarshal(output, &comment); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+	comment.Kind = CommentKindIssue
+
+	return &comment, nil
+}
+
+// get performs a REST GET request against apiPath with retry logic and
+// returns the raw response body. Paginated requests follow the Link header
+// and return the pages merged into a single JSON array. notFound is the
+// error message used for 404 responses.
+func (c *Client) get(apiPath string, paginate bool, notFound string) ([]byte, error) {
+	if !paginate {
+		body, _, err := c.request(apiPath, notFound)
+		return body, err
+	}
+
+	items := []json.RawMessage{}
+	next := withPerPage(apiPath)
+	for next != "" {
+		body, header, err := c.request(next, notFound)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []json.RawMessage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+		}
+		items = append(items, page...)
+		next = nextPageURL(header.Get("Link"))
+	}
+
+	return json.Marshal(items)
+}
+
+// request performs a single REST GET request with retry logic and returns
+// the response body and headers
+func (c *Client) request(apiPath, notFound string) ([]byte, http.Header, error) {
+	var body []byte
+	var header http.Header
+	err := c.do(notFound, func(ctx context.Context, api API) error {
+		resp, err := api.Request(ctx, http.MethodGet, apiPath, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, err = io.ReadAll(resp.Body)
+		header = resp.Header
+		if err != nil {
+			return err
+		}
+
+		// Hold back further requests once the budget is spent rather than
+		// running into a 403 on the next one
+		if limit := parseRateLimit(header); limit.Exhausted() && !limit.Reset.IsZero() {
+			c.governor.Pause(apiHost(c.host), time.Until(limit.Reset))
+		}
+		return nil
+	})
+	return body, header, err
+}
+
+// graphQL runs a GraphQL query with retry logic, decoding its data into
+// response. notFound is the error message used for missing resources.
+func (c *Client) graphQL(query string, variables map[string]interface{}, response interface{}, notFound string) error {
+	return c.do(notFound, func(ctx context.Context, api API) error {
+		return api.GraphQL(ctx, query, variables, response)
+	})
+}
+
+// do runs call with retry logic. Server and network errors back off
+// exponentially; rate limits pause the host through the governor until the
+// limit resets. Failures are returned as *APIError, possibly wrapped.
+func (c *Client) do(notFound string, call func(ctx context.Context, api API) error) error {
+	api, err := c.client()
+	if err != nil {
+		return &APIError{StatusCode: http.StatusUnauthorized, Message: authErrorMessage, Err: err, RateLimit: RateLimit{Remaining: -1}}
+	}
+
 	// Retry loop with exponential backoff
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		cmd := exec.Command("gh", "api", "--paginate", apiPath)
-		
-		output, err := cmd.Output()
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				stderr := string(exitErr.Stderr)
-				
-				// Don't retry on authentication or not found errors
-				if strings.Contains(stderr, "Not Found") || strings.Contains(stderr, "404") {
-					return nil, fmt.Errorf("issue/PR %s not found in %s/%s", num, owner, repo)
-				}
-				if strings.Contains(stderr, "Bad credentials") || strings.Contains(stderr, "401") {
-					return nil, fmt.Errorf("authentication failed. Please run 'gh auth login'")
-				}
-				
-				// Retry on rate limiting or server errors
-				if attempt < c.maxRetries && c.isRetryableGitHubError(stderr) {
-					delay := c.calculateBackoffDelay(attempt)
-					time.Sleep(delay)
-					continue
-				}
-				
-				return nil, fmt.Errorf("GitHub API error after %d attempts: %s", attempt+1, stderr)
-			}
-			
-			// Retry on general execution errors
-			if attempt < c.maxRetries {
-				delay := c.calculateBackoffDelay(attempt)
-				time.Sleep(delay)
+		if err := c.governor.Wait(context.Background(), apiHost(c.host)); err != nil {
+			return err
+		}
+
+		ctx, cancel := c.context()
+		err := call(ctx, api)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		apiErr := newAPIError(err)
+
+		// Don't retry on authentication or not found errors
+		if apiErr.NotFound() {
+			apiErr.Message = notFound
+			return apiErr
+		}
+		if apiErr.Unauthorized() {
+			apiErr.Message = authErrorMessage
+			return apiErr
+		}
+
+		// Rate limits pause every request to the host until they reset
+		if attempt < c.maxRetries && apiErr.RateLimited() {
+			if c.governor.Pause(apiHost(c.host), apiErr.RateLimit.Wait(c.calculateBackoffDelay(attempt))) {
 				continue
 			}
-			
-			return nil, fmt.Errorf("failed to execute gh command after %d attempts: %w", attempt+1, err)
+			return fmt.Errorf("GitHub API error after %d attempts: %w", attempt+1, apiErr)
 		}
 
-		var comments []*Comment
-		if err := json.Unmarshal(output, &comments); err != nil {
-			return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+		// Retry on server and network errors
+		if attempt < c.maxRetries && apiErr.Retryable() {
+			time.Sleep(c.calculateBackoffDelay(attempt))
+			continue
+		}
+
+		return fmt.Errorf("GitHub API error after %d attempts: %w", attempt+1, apiErr)
+	}
+
+	return fmt.Errorf("unexpected error in retry loop")
+}
+
+// client returns the API implementation, creating the go-gh backed one on
+// first use
+func (c *Client) client() (API, error) {
+	c.apiOnce.Do(func() {
+		if c.api != nil {
+			return
 		}
+		api, err := newGHAPI(c.opts)
+		if err != nil {
+			c.apiErr = err
+			return
+		}
+		c.api = api
+	})
+	return c.api, c.apiErr
+}
 
-		return comments, nil
+// context returns the context for a single request, bounded by the client
+// timeout when one is set
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(context.Background(), c.timeout)
 	}
+	return context.WithCancel(context.Background())
+}
 
-	return nil, fmt.Errorf("unexpected error in retry loop")
+// withPerPage adds the page size to a REST path unless it already sets one
+func withPerPage(apiPath string) string {
+	if strings.Contains(apiPath, "per_page=") {
+		return apiPath
+	}
+	separator := "?"
+	if strings.Contains(apiPath, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%sper_page=%d", apiPath, separator, perPage)
+}
+
+// linkNextRegex matches the next page entry of a Link header
+var linkNextRegex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="next"`)
+
+// nextPageURL returns the URL of the next page from a Link header, or an
+// empty string on the last page
+func nextPageURL(link string) string {
+	if m := linkNextRegex.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// SortComments orders comments chronologically by creation time.
+// Comments created at the same instant are ordered by ID, which GitHub
+// assigns monotonically, so the result is stable across runs.
+func SortComments(comments []*Comment) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+}
+
+// Host returns the hostname the client talks to
+func (c *Client) Host() string {
+	return c.host
+}
+
+// ConfiguredHosts returns the hosts the user is logged in to with gh,
+// with gh's default host (GH_HOST or github.com) used for short-form targets
+func ConfiguredHosts() Hosts {
+	defaultHost, _ := auth.DefaultHost()
+	return Hosts{
+		Default: defaultHost,
+		Known:   append(auth.KnownHosts(), DefaultHost),
+	}
+}
+
+// TokenForHost returns the gh auth token for the given host, or an empty
+// string if the user is not logged in to it
+func TokenForHost(host string) string {
+	token, _ := auth.TokenForHost(host)
+	return token
 }
 
 // IsGHCliAvailable checks if gh CLI is available and authenticated
@@ -169,33 +459,6 @@ func IsGHCliAvailable() error {
 	return nil
 }
 
-// isRetryableGitHubError determines if a GitHub API error should trigger a retry
-func (c *Client) isRetryableGitHubError(stderr string) bool {
-	errorStr := strings.ToLower(stderr)
-	
-	// Retry on rate limiting and server errors
-	retryableErrors := []string{
-		"rate limit",
-		"api rate limit",
-		"secondary rate limit",
-		"server error",
-		"internal server error",
-		"bad gateway",
-		"service unavailable",
-		"gateway timeout",
-		"timeout",
-		"temporary failure",
-	}
-	
-	for _, retryable := range retryableErrors {
-		if strings.Contains(errorStr, retryable) {
-			return true
-		}
-	}
-	
-	return false
-}
-
 // calculateBackoffDelay calculates exponential backoff delay for GitHub API
 func (c *Client) calculateBackoffDelay(attempt int) time.Duration {
 	// Exponential backoff: base_delay * 2^attempt
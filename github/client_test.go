@@ -1,6 +1,8 @@
 package github
 
 import (
+	"encoding/json"
+	"os"
 	"testing"
 	"time"
 )
@@ -18,6 +20,20 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestNewClientForHost(t *testing.T) {
+	client := NewClientForHost("ghe.example.com", time.Second)
+	if client.Host() != "ghe.example.com" {
+		t.Errorf("Host() = %q, want ghe.example.com", client.Host())
+	}
+
+	if got := NewClient(time.Second).Host(); got != DefaultHost {
+		t.Errorf("NewClient Host() = %q, want %q", got, DefaultHost)
+	}
+	if got := NewClientForHost("", time.Second).Host(); got != DefaultHost {
+		t.Errorf("NewClientForHost(\"\") Host() = %q, want %q", got, DefaultHost)
+	}
+}
+
 func TestClient_FetchIssue_ValidationErrors(t *testing.T) {
 	client := NewClient(30 * time.Second)
 	
@@ -217,6 +233,50 @@ func TestClient_TimeoutVariations(t *testing.T) {
 	}
 }
 
+func TestSortComments(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	comments := []*Comment{
+		{ID: 3, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 2, CreatedAt: base},
+		{ID: 1, CreatedAt: base},
+		{ID: 4, CreatedAt: base.Add(time.Hour)},
+	}
+
+	SortComments(comments)
+
+	wantIDs := []int{1, 2, 4, 3}
+	for i, comment := range comments {
+		if comment.ID != wantIDs[i] {
+			t.Errorf("comments[%d].ID = %d, want %d", i, comment.ID, wantIDs[i])
+		}
+	}
+}
+
+func TestComment_UnmarshalAuthor(t *testing.T) {
+	data, err := os.ReadFile("../testdata/responses/comments.json")
+	if err != nil {
+		t.Fatalf("Failed to read comments fixture: %v", err)
+	}
+
+	var comments []*Comment
+	if err := json.Unmarshal(data, &comments); err != nil {
+		t.Fatalf("Failed to unmarshal comments: %v", err)
+	}
+
+	if len(comments) == 0 {
+		t.Fatal("Expected at least one comment in fixture")
+	}
+	if comments[0].User.Login != "maintainer" {
+		t.Errorf("comments[0].User.Login = %q, want %q", comments[0].User.Login, "maintainer")
+	}
+	if comments[0].CreatedAt.IsZero() {
+		t.Error("comments[0].CreatedAt should be parsed")
+	}
+	if comments[0].AuthorAssociation != "MEMBER" {
+		t.Errorf("comments[0].AuthorAssociation = %q, want %q", comments[0].AuthorAssociation, "MEMBER")
+	}
+}
+
 // Test helper functions
 func TestContainsString(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,225 @@
+package github
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// discussionQuery fetches a discussion with its comments and their threaded
+// replies. Comments are paginated through $endCursor; comments with more
+// replies than fit on a page are finished with discussionRepliesQuery.
+const discussionQuery = `query($owner: String!, $repo: String!, $number: Int!, $endCursor: String) {
+  repository(owner: $owner, name: $repo) {
+    discussion(number: $number) {
+      number
+      title
+      body
+      bodyHTML
+      createdAt
+      author { login }
+      authorAssociation
+      comments(first: 50, after: $endCursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          databaseId
+          body
+          bodyHTML
+          createdAt
+          updatedAt
+          author { login }
+          authorAssociation
+          replies(first: 100) {
+            ...discussionReplies
+          }
+        }
+      }
+    }
+  }
+}
+` + discussionRepliesFragment
+
+// discussionRepliesQuery fetches the next page of replies to a discussion
+// comment
+const discussionRepliesQuery = `query($id: ID!, $cursor: String) {
+  node(id: $id) {
+    ... on DiscussionComment {
+      replies(first: 100, after: $cursor) {
+        ...discussionReplies
+      }
+    }
+  }
+}
+` + discussionRepliesFragment
+
+// discussionRepliesFragment selects a page of discussion comment replies
+const discussionRepliesFragment = `
+fragment discussionReplies on DiscussionCommentConnection {
+  pageInfo { hasNextPage endCursor }
+  nodes {
+    databaseId
+    body
+    bodyHTML
+    createdAt
+    updatedAt
+    author { login }
+    authorAssociation
+  }
+}`
+
+// graphQLAuthor is the GraphQL shape of an actor; it is null for deleted users
+type graphQLAuthor struct {
+	Login string `json:"login"`
+}
+
+// discussionComment is the GraphQL shape of a discussion comment or reply
+type discussionComment struct {
+	ID         string         `json:"id"`
+	DatabaseID int            `json:"databaseId"`
+	Body       string         `json:"body"`
+	BodyHTML   string         `json:"bodyHTML"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+	Author     *graphQLAuthor `json:"author"`
+	// AuthorAssociation uses the same values as the REST API
+	AuthorAssociation string               `json:"authorAssociation"`
+	Replies           discussionConnection `json:"replies"`
+}
+
+// discussionConnection is a page of discussion comments or replies
+type discussionConnection struct {
+	PageInfo pageInfo            `json:"pageInfo"`
+	Nodes    []discussionComment `json:"nodes"`
+}
+
+// discussionRepliesResponse is the GraphQL data for discussionRepliesQuery
+type discussionRepliesResponse struct {
+	Node *struct {
+		Replies discussionConnection `json:"replies"`
+	} `json:"node"`
+}
+
+// discussionResponse is the GraphQL data for discussionQuery
+type discussionResponse struct {
+	Repository struct {
+		Discussion *struct {
+			Number    int            `json:"number"`
+			Title     string         `json:"title"`
+			Body      string         `json:"body"`
+			BodyHTML  string         `json:"bodyHTML"`
+			CreatedAt time.Time      `json:"createdAt"`
+			Author    *graphQLAuthor `json:"author"`
+
+			AuthorAssociation string `json:"authorAssociation"`
+
+			Comments discussionConnection `json:"comments"`
+		} `json:"discussion"`
+	} `json:"repository"`
+}
+
+// FetchDiscussion retrieves a discussion through GraphQL. The discussion
+// itself is returned as an Issue so it can flow through the same pipeline,
+// along with its comments and their threaded replies.
+func (c *Client) FetchDiscussion(owner, repo, num string) (*Issue, []*Comment, error) {
+	if owner == "" || repo == "" || num == "" {
+		return nil, nil, fmt.Errorf("owner, repo, and number are required")
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid discussion number: %s", num)
+	}
+
+	notFound := fmt.Sprintf("discussion %s not found in %s/%s", num, owner, repo)
+	variables := map[string]interface{}{
+		"owner":     owner,
+		"repo":      repo,
+		"number":    number,
+		"endCursor": nil,
+	}
+
+	var issue *Issue
+	var comments []*Comment
+	for {
+		var page discussionResponse
+		if err := c.graphQL(discussionQuery, variables, &page, notFound); err != nil {
+			return nil, nil, err
+		}
+
+		discussion := page.Repository.Discussion
+		if discussion == nil {
+			return nil, nil, fmt.Errorf("%s", notFound)
+		}
+		if issue == nil {
+			issue = &Issue{
+				Number:    discussion.Number,
+				Title:     discussion.Title,
+				Body:      discussion.Body,
+				BodyHTML:  discussion.BodyHTML,
+				User:      discussion.Author.user(),
+				CreatedAt: discussion.CreatedAt,
+
+				AuthorAssociation: discussion.AuthorAssociation,
+			}
+		}
+		for _, node := range discussion.Comments.Nodes {
+			comments = append(comments, node.toComment(CommentKindDiscussion))
+			replies, err := c.discussionReplies(node.ID, node.Replies, notFound)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, reply := range replies {
+				comments = append(comments, reply.toComment(CommentKindDiscussionReply))
+			}
+		}
+
+		if !discussion.Comments.PageInfo.more() {
+			break
+		}
+		variables["endCursor"] = discussion.Comments.PageInfo.EndCursor
+	}
+
+	return issue, comments, nil
+}
+
+// discussionReplies returns the replies to a discussion comment, following
+// their cursor from the first page until all have been read
+func (c *Client) discussionReplies(id string, page discussionConnection, notFound string) ([]discussionComment, error) {
+	nodes := page.Nodes
+	for page.PageInfo.more() && id != "" {
+		var next discussionRepliesResponse
+		variables := map[string]interface{}{"id": id, "cursor": page.PageInfo.EndCursor}
+		if err := c.graphQL(discussionRepliesQuery, variables, &next, notFound); err != nil {
+			return nil, err
+		}
+		if next.Node == nil {
+			break
+		}
+		page = next.Node.Replies
+		nodes = append(nodes, page.Nodes...)
+	}
+	return nodes, nil
+}
+
+// toComment maps a discussion comment onto the Comment type
+func (d discussionComment) toComment(kind CommentKind) *Comment {
+	return &Comment{
+		ID:        d.DatabaseID,
+		Kind:      kind,
+		Body:      d.Body,
+		BodyHTML:  d.BodyHTML,
+		User:      d.Author.user(),
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+
+		AuthorAssociation: d.AuthorAssociation,
+	}
+}
+
+// user converts a possibly-null GraphQL author into a User
+func (a *graphQLAuthor) user() User {
+	if a == nil {
+		return User{}
+	}
+	return User{Login: a.Login}
+}
@@ -0,0 +1,163 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_FetchDiscussion(t *testing.T) {
+	pages := map[string]string{
+		"": `{"data":{"repository":{"discussion":{
+			"number": 45, "title": "Mockups", "body": "![v1](https://example.com/v1.png)", "bodyHTML": "<p>v1</p>",
+			"createdAt": "2024-03-01T09:00:00Z", "author": {"login": "designer"},
+			"comments": {"pageInfo": {"hasNextPage": true, "endCursor": "abc"}, "nodes": [
+				{"databaseId": 10, "body": "first", "createdAt": "2024-03-02T09:00:00Z", "author": {"login": "pm"}, "authorAssociation": "MEMBER",
+				 "replies": {"nodes": [
+					{"databaseId": 11, "body": "reply", "createdAt": "2024-03-02T10:00:00Z", "author": null}
+				 ]}}
+			]}}}}}`,
+		"abc": `{"data":{"repository":{"discussion":{
+			"number": 45, "title": "Mockups", "body": "ignored",
+			"comments": {"pageInfo": {"hasNextPage": false, "endCursor": "def"}, "nodes": [
+				{"databaseId": 12, "body": "second", "createdAt": "2024-03-03T09:00:00Z", "author": {"login": "dev"}, "replies": {"nodes": []}}
+			]}}}}}`,
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" || r.Method != http.MethodPost {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode GraphQL request: %v", err)
+		}
+		if req.Variables["owner"] != "octo" || req.Variables["number"] != float64(45) {
+			t.Errorf("Unexpected variables: %v", req.Variables)
+		}
+		cursor, _ := req.Variables["endCursor"].(string)
+		w.Write([]byte(pages[cursor]))
+	})
+
+	issue, comments, err := client.FetchDiscussion("octo", "repo", "45")
+	if err != nil {
+		t.Fatalf("FetchDiscussion failed: %v", err)
+	}
+
+	if issue.Number != 45 || issue.User.Login != "designer" || !strings.Contains(issue.Body, "v1.png") {
+		t.Errorf("Unexpected discussion: %+v", issue)
+	}
+	if issue.IsPullRequest() {
+		t.Error("A discussion should not be a pull request")
+	}
+	if !issue.CreatedAt.Equal(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %v", issue.CreatedAt)
+	}
+
+	if len(comments) != 3 {
+		t.Fatalf("Got %d comments, want 3", len(comments))
+	}
+	if comments[0].AuthorAssociation != "MEMBER" {
+		t.Errorf("AuthorAssociation = %q, want MEMBER", comments[0].AuthorAssociation)
+	}
+	want := []struct {
+		id     int
+		kind   CommentKind
+		author string
+	}{
+		{10, CommentKindDiscussion, "pm"},
+		{11, CommentKindDiscussionReply, ""},
+		{12, CommentKindDiscussion, "dev"},
+	}
+	for i, w := range want {
+		if comments[i].ID != w.id || comments[i].Kind != w.kind || comments[i].User.Login != w.author {
+			t.Errorf("comment %d = {%d %s %q}, want {%d %s %q}", i, comments[i].ID, comments[i].Kind, comments[i].User.Login, w.id, w.kind, w.author)
+		}
+	}
+}
+
+func TestClient_FetchDiscussion_ReplyPages(t *testing.T) {
+	var requests []map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode GraphQL request: %v", err)
+		}
+		requests = append(requests, req.Variables)
+
+		if len(requests) == 1 {
+			w.Write([]byte(`{"data":{"repository":{"discussion":{
+				"number": 46, "title": "Busy thread",
+				"comments": {"pageInfo": {"hasNextPage": false}, "nodes": [
+					{"id": "C1", "databaseId": 10, "body": "first", "replies": {"pageInfo": {"hasNextPage": true, "endCursor": "r1"}, "nodes": [
+						{"databaseId": 11, "body": "reply one"}
+					]}},
+					{"id": "C2", "databaseId": 20, "body": "second", "replies": {"pageInfo": {"hasNextPage": false}, "nodes": []}}
+				]}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"node":{"replies":{"pageInfo": {"hasNextPage": false, "endCursor": "r2"}, "nodes": [
+			{"databaseId": 12, "body": "reply two"}
+		]}}}}`))
+	})
+
+	_, comments, err := client.FetchDiscussion("octo", "repo", "46")
+	if err != nil {
+		t.Fatalf("FetchDiscussion failed: %v", err)
+	}
+
+	if len(requests) != 2 || requests[1]["id"] != "C1" || requests[1]["cursor"] != "r1" {
+		t.Fatalf("GraphQL requests = %v, want a second page of replies to C1 after r1", requests)
+	}
+	wantIDs := []int{10, 11, 12, 20}
+	if len(comments) != len(wantIDs) {
+		t.Fatalf("Got %d comments, want %d", len(comments), len(wantIDs))
+	}
+	for i, comment := range comments {
+		if comment.ID != wantIDs[i] {
+			t.Errorf("comments[%d] = %d, want %d", i, comment.ID, wantIDs[i])
+		}
+	}
+	if comments[2].Kind != CommentKindDiscussionReply {
+		t.Errorf("comments[2] kind = %s, want %s", comments[2].Kind, CommentKindDiscussionReply)
+	}
+}
+
+func TestClient_FetchDiscussion_NotFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"null discussion", `{"data":{"repository":{"discussion":null}}}`},
+		{"graphql error", `{"data":{"repository":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a Repository"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.response))
+			})
+
+			_, _, err := client.FetchDiscussion("octo", "repo", "7")
+			if err == nil || err.Error() != "discussion 7 not found in octo/repo" {
+				t.Errorf("FetchDiscussion error = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestClient_FetchDiscussion_ValidationErrors(t *testing.T) {
+	client := NewClient(time.Second)
+	if _, _, err := client.FetchDiscussion("", "repo", "1"); err == nil {
+		t.Error("Expected error for empty owner")
+	}
+	if _, _, err := client.FetchDiscussion("owner", "repo", "abc"); err == nil {
+		t.Error("Expected error for non-numeric number")
+	}
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/kojikawamura/gh-ccimg/ratelimit"
+)
+
+// RateLimit is the rate-limit state reported by a GitHub API response
+type RateLimit struct {
+	Limit      int           // X-RateLimit-Limit, 0 if absent
+	Remaining  int           // X-RateLimit-Remaining, -1 if absent
+	Reset      time.Time     // X-RateLimit-Reset, zero if absent
+	RetryAfter time.Duration // Retry-After, 0 if absent
+}
+
+// parseRateLimit reads the rate-limit headers of a response
+func parseRateLimit(header http.Header) RateLimit {
+	limit := RateLimit{Remaining: -1}
+	if header == nil {
+		return limit
+	}
+	if v, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		limit.Limit = v
+	}
+	if v, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		limit.Remaining = v
+	}
+	if v, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil && v > 0 {
+		limit.Reset = time.Unix(v, 0)
+	}
+	if v, ok := ratelimit.ParseRetryAfter(header.Get("Retry-After"), time.Now()); ok && v > 0 {
+		limit.RetryAfter = v
+	}
+	return limit
+}
+
+// Exhausted reports whether the primary rate limit has been used up
+func (r RateLimit) Exhausted() bool {
+	return r.Remaining == 0
+}
+
+// Wait returns how long to hold back before the limit allows another
+// request: Retry-After when given, otherwise until the reset time. fallback
+// is used for limits that report neither, such as some secondary limits.
+func (r RateLimit) Wait(fallback time.Duration) time.Duration {
+	switch {
+	case r.RetryAfter > 0:
+		return r.RetryAfter
+	case !r.Reset.IsZero():
+		if wait := time.Until(r.Reset); wait > 0 {
+			return wait
+		}
+		return 0
+	default:
+		return fallback
+	}
+}
+
+// APIError is a failed GitHub API request. StatusCode is 0 when no HTTP
+// response was received.
+type APIError struct {
+	StatusCode int
+	Message    string
+	URL        string
+	RateLimit  RateLimit
+	Err        error
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("GitHub API returned HTTP %d for %s", e.StatusCode, e.URL)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("GitHub API request failed: %v", e.Err)
+	}
+	return "GitHub API request failed"
+}
+
+// Unwrap returns the underlying error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// NotFound reports whether the resource does not exist or is not visible
+func (e *APIError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// Unauthorized reports whether the credentials were rejected
+func (e *APIError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// RateLimited reports whether the request was rejected by the primary or
+// secondary rate limit
+func (e *APIError) RateLimited() bool {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if e.StatusCode != http.StatusForbidden {
+		return false
+	}
+	if e.RateLimit.Exhausted() || e.RateLimit.RetryAfter > 0 {
+		return true
+	}
+	return e.Err != nil && strings.Contains(strings.ToLower(e.Err.Error()), "rate limit")
+}
+
+// Retryable reports whether the request may succeed if repeated
+func (e *APIError) Retryable() bool {
+	if e.StatusCode == 0 {
+		return true // Network failure
+	}
+	return e.StatusCode >= 500 || e.RateLimited()
+}
+
+// newAPIError converts an error returned by the go-gh clients into an APIError
+func newAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) {
+		e := &APIError{
+			StatusCode: httpErr.StatusCode,
+			RateLimit:  parseRateLimit(httpErr.Headers),
+			Err:        err,
+		}
+		if httpErr.RequestURL != nil {
+			e.URL = httpErr.RequestURL.String()
+		}
+		return e
+	}
+
+	var gqlErr *api.GraphQLError
+	if errors.As(err, &gqlErr) {
+		e := &APIError{StatusCode: http.StatusOK, Err: err, RateLimit: RateLimit{Remaining: -1}}
+		for _, item := range gqlErr.Errors {
+			switch item.Type {
+			case "NOT_FOUND":
+				e.StatusCode = http.StatusNotFound
+			case "RATE_LIMITED":
+				e.StatusCode = http.StatusForbidden
+				e.RateLimit.Remaining = 0
+			}
+		}
+		return e
+	}
+
+	return &APIError{Err: err, RateLimit: RateLimit{Remaining: -1}}
+}
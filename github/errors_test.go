@@ -0,0 +1,113 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", "1700000000")
+	header.Set("Retry-After", "30")
+
+	got := parseRateLimit(header)
+	want := RateLimit{Limit: 5000, Remaining: 0, Reset: time.Unix(1700000000, 0), RetryAfter: 30 * time.Second}
+	if got != want {
+		t.Errorf("parseRateLimit() = %+v, want %+v", got, want)
+	}
+	if !got.Exhausted() {
+		t.Error("Exhausted() = false, want true")
+	}
+
+	if empty := parseRateLimit(nil); empty.Remaining != -1 || empty.Exhausted() {
+		t.Errorf("parseRateLimit(nil) = %+v, want unknown remaining", empty)
+	}
+}
+
+func TestAPIError_Classification(t *testing.T) {
+	limited := RateLimit{Remaining: 0}
+	unknown := RateLimit{Remaining: -1}
+
+	tests := []struct {
+		name        string
+		err         *APIError
+		notFound    bool
+		rateLimited bool
+		retryable   bool
+	}{
+		{"not found", &APIError{StatusCode: 404, RateLimit: unknown}, true, false, false},
+		{"forbidden", &APIError{StatusCode: 403, RateLimit: unknown}, false, false, false},
+		{"primary rate limit", &APIError{StatusCode: 403, RateLimit: limited}, false, true, true},
+		{"secondary rate limit", &APIError{StatusCode: 403, RateLimit: RateLimit{Remaining: -1, RetryAfter: time.Minute}}, false, true, true},
+		{"too many requests", &APIError{StatusCode: 429, RateLimit: unknown}, false, true, true},
+		{"server error", &APIError{StatusCode: 503, RateLimit: unknown}, false, false, true},
+		{"network error", &APIError{Err: errors.New("connection reset"), RateLimit: unknown}, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.NotFound(); got != tt.notFound {
+				t.Errorf("NotFound() = %v, want %v", got, tt.notFound)
+			}
+			if got := tt.err.RateLimited(); got != tt.rateLimited {
+				t.Errorf("RateLimited() = %v, want %v", got, tt.rateLimited)
+			}
+			if got := tt.err.Retryable(); got != tt.retryable {
+				t.Errorf("Retryable() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	requestURL, _ := url.Parse("https://api.github.com/repos/o/r")
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	httpErr := &api.HTTPError{StatusCode: 403, Headers: header, RequestURL: requestURL, Message: "API rate limit exceeded"}
+
+	err := newAPIError(httpErr)
+	if err.StatusCode != 403 || err.URL != requestURL.String() || !err.RateLimited() {
+		t.Errorf("newAPIError(HTTPError) = %+v", err)
+	}
+	if !errors.Is(err, httpErr) {
+		t.Error("APIError should unwrap to the HTTPError")
+	}
+
+	gqlErr := &api.GraphQLError{Errors: []api.GraphQLErrorItem{{Type: "NOT_FOUND", Message: "Could not resolve"}}}
+	if err := newAPIError(gqlErr); !err.NotFound() {
+		t.Errorf("newAPIError(NOT_FOUND) = %+v, want not found", err)
+	}
+
+	gqlErr = &api.GraphQLError{Errors: []api.GraphQLErrorItem{{Type: "RATE_LIMITED"}}}
+	if err := newAPIError(gqlErr); !err.RateLimited() {
+		t.Errorf("newAPIError(RATE_LIMITED) = %+v, want rate limited", err)
+	}
+
+	if err := newAPIError(errors.New("dial tcp: timeout")); err.StatusCode != 0 || !err.Retryable() {
+		t.Errorf("newAPIError(network) = %+v, want retryable with no status", err)
+	}
+}
+
+func TestRateLimit_Wait(t *testing.T) {
+	fallback := 3 * time.Second
+
+	if got := (RateLimit{RetryAfter: time.Minute, Reset: time.Now().Add(time.Hour)}).Wait(fallback); got != time.Minute {
+		t.Errorf("Wait() with Retry-After = %v, want 1m", got)
+	}
+	if got := (RateLimit{Reset: time.Now().Add(-time.Minute)}).Wait(fallback); got != 0 {
+		t.Errorf("Wait() after reset = %v, want 0", got)
+	}
+	if got := (RateLimit{Reset: time.Now().Add(time.Minute)}).Wait(fallback); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("Wait() before reset = %v, want about 1m", got)
+	}
+	if got := (RateLimit{Remaining: -1}).Wait(fallback); got != fallback {
+		t.Errorf("Wait() without headers = %v, want fallback %v", got, fallback)
+	}
+}
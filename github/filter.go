@@ -0,0 +1,107 @@
+package github
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// dateLayout is the date-only form accepted for --since and --until
+const dateLayout = "2006-01-02"
+
+// CommentFilter selects the comments images are extracted from. The zero
+// value matches everything.
+type CommentFilter struct {
+	// Authors keeps only content by these logins; the * and ? wildcards
+	// are allowed, so "*[bot]" matches every bot account
+	Authors []string
+	// ExcludeAuthors drops content by these logins or glob patterns
+	ExcludeAuthors []string
+	// Since and Until bound the creation time; Since is inclusive and Until
+	// exclusive. Zero values leave the range open.
+	Since time.Time
+	Until time.Time
+	// Last keeps only the N most recent matching comments when positive
+	Last int
+}
+
+// Matches reports whether content by login created at the given time
+// passes the author and date filters
+func (f CommentFilter) Matches(login string, createdAt time.Time) bool {
+	if len(f.Authors) > 0 && !matchesLogin(f.Authors, login) {
+		return false
+	}
+	if matchesLogin(f.ExcludeAuthors, login) {
+		return false
+	}
+	if !f.Since.IsZero() && createdAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !createdAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the comments that pass the filter, preserving order. Last
+// is applied after the other filters, to comments sorted with SortComments.
+func (f CommentFilter) Apply(comments []*Comment) []*Comment {
+	filtered := make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		if f.Matches(comment.User.Login, comment.CreatedAt) {
+			filtered = append(filtered, comment)
+		}
+	}
+	if f.Last > 0 && len(filtered) > f.Last {
+		filtered = filtered[len(filtered)-f.Last:]
+	}
+	return filtered
+}
+
+// Validate checks the filter for contradictory or malformed settings
+func (f CommentFilter) Validate() error {
+	if f.Last < 0 {
+		return fmt.Errorf("last must not be negative, got: %d", f.Last)
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Since.Before(f.Until) {
+		return fmt.Errorf("since (%s) must be before until (%s)", f.Since.Format(time.RFC3339), f.Until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ParseFilterTime parses a --since or --until value, either RFC 3339 or a
+// YYYY-MM-DD date. A bare date used as an upper bound covers the whole day.
+func ParseFilterTime(value string, upperBound bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD or RFC 3339)", value)
+	}
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// loginPatternEscaper makes brackets in logins such as "dependabot[bot]"
+// literal, leaving only the * and ? wildcards
+var loginPatternEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+
+// matchesLogin reports whether login matches any of the patterns, ignoring case
+func matchesLogin(patterns []string, login string) bool {
+	login = strings.ToLower(login)
+	for _, pattern := range patterns {
+		pattern = loginPatternEscaper.Replace(strings.ToLower(strings.TrimPrefix(pattern, "@")))
+		if matched, _ := path.Match(pattern, login); matched {
+			return true
+		}
+	}
+	return false
+}
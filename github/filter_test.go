@@ -0,0 +1,109 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC)
+}
+
+func testComments() []*Comment {
+	return []*Comment{
+		{ID: 1, User: User{Login: "alice"}, CreatedAt: day(1)},
+		{ID: 2, User: User{Login: "dependabot[bot]"}, CreatedAt: day(2)},
+		{ID: 3, User: User{Login: "Bob"}, CreatedAt: day(3)},
+		{ID: 4, User: User{Login: "alice"}, CreatedAt: day(4)},
+		{ID: 5, User: User{Login: "github-actions[bot]"}, CreatedAt: day(5)},
+	}
+}
+
+func commentIDs(comments []*Comment) []int {
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
+
+func TestCommentFilter_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter CommentFilter
+		want   []int
+	}{
+		{"zero value", CommentFilter{}, []int{1, 2, 3, 4, 5}},
+		{"author", CommentFilter{Authors: []string{"alice"}}, []int{1, 4}},
+		{"author case and @", CommentFilter{Authors: []string{"@bob"}}, []int{3}},
+		{"exclude bots", CommentFilter{ExcludeAuthors: []string{"*[bot]"}}, []int{1, 3, 4}},
+		{"exclude exact bot", CommentFilter{ExcludeAuthors: []string{"dependabot[bot]"}}, []int{1, 3, 4, 5}},
+		{"wildcard author", CommentFilter{Authors: []string{"ali?e"}}, []int{1, 4}},
+		{"since", CommentFilter{Since: day(3)}, []int{3, 4, 5}},
+		{"until", CommentFilter{Until: day(3)}, []int{1, 2}},
+		{"range", CommentFilter{Since: day(2), Until: day(5)}, []int{2, 3, 4}},
+		{"last", CommentFilter{Last: 2}, []int{4, 5}},
+		{"last after filters", CommentFilter{ExcludeAuthors: []string{"*[bot]"}, Last: 2}, []int{3, 4}},
+		{"last larger than matches", CommentFilter{Authors: []string{"alice"}, Last: 10}, []int{1, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commentIDs(tt.filter.Apply(testComments()))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Apply() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommentFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  CommentFilter
+		wantErr bool
+	}{
+		{"zero value", CommentFilter{}, false},
+		{"negative last", CommentFilter{Last: -1}, true},
+		{"since after until", CommentFilter{Since: day(5), Until: day(1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFilterTime(t *testing.T) {
+	tests := []struct {
+		value      string
+		upperBound bool
+		want       time.Time
+		wantErr    bool
+	}{
+		{"", false, time.Time{}, false},
+		{"2024-03-01", false, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03-01", true, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03-01T09:30:00Z", true, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC), false},
+		{"yesterday", false, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFilterTime(tt.value, tt.upperBound)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFilterTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseFilterTime(%q, %v) = %v, want %v", tt.value, tt.upperBound, got, tt.want)
+		}
+	}
+}
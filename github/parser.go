@@ -9,60 +9,257 @@ import (
 
 var (
 	// Match patterns for different GitHub URL formats
-	shortFormRegex = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)#(\d+)$`)
-	issueURLRegex  = regexp.MustCompile(`^https://github\.com/([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)/issues/(\d+)(?:[/?#].*)?$`)
-	pullURLRegex   = regexp.MustCompile(`^https://github\.com/([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)/pull/(\d+)(?:[/?#].*)?$`)
+	shortFormRegex       = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)#(\d+)$`)
+	hostShortFormRegex   = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9.\-]*(?::\d+)?)/([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)#(\d+)$`)
+	issueURLRegex        = regexp.MustCompile(`^https://([^/\s]+)/([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)/issues/(\d+)(?:[/?#].*)?$`)
+	pullURLRegex         = regexp.MustCompile(`^https://([^/\s]+)/([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)/pull/(\d+)(?:[/?#].*)?$`)
+	discussionURLRegex   = regexp.MustCompile(`^https://([^/\s]+)/([a-zA-Z0-9][a-zA-Z0-9\-]{0,38})/([a-zA-Z0-9._\-]+)/discussions/(\d+)(?:[/?#].*)?$`)
+	commentFragmentRegex = regexp.MustCompile(`^(issuecomment-|discussion_r|pullrequestreview-)(\d+)$`)
+	rangeSuffixRegex     = regexp.MustCompile(`^(.+#)(\d+)-(\d+)$`)
 )
 
+// MaxTargetRange caps how many targets a single OWNER/REPO#START-END range may expand to
+const MaxTargetRange = 1000
+
+// TargetKind distinguishes the kinds of thread a target can point at
+type TargetKind string
+
+const (
+	// TargetIssue is an issue or pull request
+	TargetIssue TargetKind = ""
+	// TargetDiscussion is a GitHub Discussion
+	TargetDiscussion TargetKind = "discussion"
+)
+
+// Target identifies an issue, pull request or discussion on a GitHub host
+type Target struct {
+	Host   string
+	Owner  string
+	Repo   string
+	Number string
+	Kind   TargetKind
+
+	// CommentKind and CommentID narrow the target to a single comment or
+	// review when it was given as a permalink; CommentID is 0 otherwise
+	CommentKind CommentKind
+	CommentID   int
+}
+
+// commentFragments maps comment kinds to their permalink fragment prefixes
+var commentFragments = map[CommentKind]string{
+	CommentKindIssue:         "issuecomment-",
+	CommentKindReviewComment: "discussion_r",
+	CommentKindReview:        "pullrequestreview-",
+}
+
+// IsComment reports whether the target is a single comment or review
+func (t Target) IsComment() bool {
+	return t.CommentID != 0
+}
+
+// Fragment returns the permalink fragment of a comment target, such as
+// "issuecomment-123", or "" for whole threads
+func (t Target) Fragment() string {
+	if !t.IsComment() {
+		return ""
+	}
+	return fmt.Sprintf("%s%d", commentFragments[t.CommentKind], t.CommentID)
+}
+
+// IsDiscussion reports whether the target is a discussion
+func (t Target) IsDiscussion() bool {
+	return t.Kind == TargetDiscussion
+}
+
+// String returns the target in OWNER/REPO#NUM form, prefixed with the host
+// when it is not github.com. Discussions and comment permalinks, which have
+// no short form, are returned as their URL.
+func (t Target) String() string {
+	if t.IsDiscussion() {
+		host := t.Host
+		if host == "" {
+			host = DefaultHost
+		}
+		return fmt.Sprintf("https://%s/%s/%s/discussions/%s", host, t.Owner, t.Repo, t.Number)
+	}
+	if t.IsComment() {
+		host := t.Host
+		if host == "" {
+			host = DefaultHost
+		}
+		kind := "pull"
+		if t.CommentKind == CommentKindIssue {
+			kind = "issues"
+		}
+		return fmt.Sprintf("https://%s/%s/%s/%s/%s#%s", host, t.Owner, t.Repo, kind, t.Number, t.Fragment())
+	}
+	short := fmt.Sprintf("%s/%s#%s", t.Owner, t.Repo, t.Number)
+	if t.Host == "" || t.Host == DefaultHost {
+		return short
+	}
+	return t.Host + "/" + short
+}
+
+// Hosts is the set of GitHub hosts that targets may live on
+type Hosts struct {
+	// Default is used for targets that do not name a host
+	Default string
+	// Known lists every accepted host; Default is always accepted
+	Known []string
+}
+
+// contains reports whether host is one of the accepted hosts
+func (h Hosts) contains(host string) bool {
+	host = strings.ToLower(host)
+	if host == strings.ToLower(h.defaultHost()) {
+		return true
+	}
+	for _, known := range h.Known {
+		if host == strings.ToLower(known) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultHost returns the default host, falling back to github.com
+func (h Hosts) defaultHost() string {
+	if h.Default == "" {
+		return DefaultHost
+	}
+	return h.Default
+}
+
 // ParseTarget parses a GitHub target into owner, repo, and issue/PR number.
 // Supports three formats:
 //   - OWNER/REPO#NUM
 //   - https://github.com/OWNER/REPO/issues/NUM
 //   - https://github.com/OWNER/REPO/pull/NUM
 func ParseTarget(input string) (owner, repo, num string, err error) {
+	target, err := ParseTargetForHosts(input, Hosts{Default: DefaultHost})
+	if err != nil {
+		return "", "", "", err
+	}
+	return target.Owner, target.Repo, target.Number, nil
+}
+
+// ParseTargetForHosts parses a GitHub target on any of the given hosts.
+// In addition to the formats accepted by ParseTarget it supports:
+//   - HOST/OWNER/REPO#NUM
+//   - https://HOST/OWNER/REPO/issues/NUM
+//   - https://HOST/OWNER/REPO/pull/NUM
+//   - https://HOST/OWNER/REPO/discussions/NUM
+//
+// Issue and pull request URLs ending in an #issuecomment-ID, #discussion_rID
+// or #pullrequestreview-ID fragment target only that comment or review.
+func ParseTargetForHosts(input string, hosts Hosts) (*Target, error) {
 	if input == "" {
-		return "", "", "", fmt.Errorf("target cannot be empty")
+		return nil, fmt.Errorf("target cannot be empty")
 	}
 
 	input = strings.TrimSpace(input)
 
+	var target *Target
+
 	// Try short form: OWNER/REPO#NUM
 	if matches := shortFormRegex.FindStringSubmatch(input); matches != nil {
-		owner = matches[1]
-		repo = matches[2]
-		num = matches[3]
-		
-		if err := validateComponents(owner, repo, num); err != nil {
-			return "", "", "", err
-		}
-		return owner, repo, num, nil
+		target = &Target{Host: hosts.defaultHost(), Owner: matches[1], Repo: matches[2], Number: matches[3]}
+	} else if matches := hostShortFormRegex.FindStringSubmatch(input); matches != nil {
+		// Try host short form: HOST/OWNER/REPO#NUM
+		target = &Target{Host: matches[1], Owner: matches[2], Repo: matches[3], Number: matches[4]}
+	} else if matches := issueURLRegex.FindStringSubmatch(input); matches != nil {
+		// Try issue URL: https://HOST/OWNER/REPO/issues/NUM
+		target = &Target{Host: matches[1], Owner: matches[2], Repo: matches[3], Number: matches[4]}
+		target.CommentKind, target.CommentID = parseCommentFragment(input)
+	} else if matches := pullURLRegex.FindStringSubmatch(input); matches != nil {
+		// Try pull request URL: https://HOST/OWNER/REPO/pull/NUM
+		target = &Target{Host: matches[1], Owner: matches[2], Repo: matches[3], Number: matches[4]}
+		target.CommentKind, target.CommentID = parseCommentFragment(input)
+	} else if matches := discussionURLRegex.FindStringSubmatch(input); matches != nil {
+		// Try discussion URL: https://HOST/OWNER/REPO/discussions/NUM
+		target = &Target{Host: matches[1], Owner: matches[2], Repo: matches[3], Number: matches[4], Kind: TargetDiscussion}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("invalid target format. Expected:\n  - OWNER/REPO#NUM\n  - HOST/OWNER/REPO#NUM\n  - https://HOST/OWNER/REPO/issues/NUM\n  - https://HOST/OWNER/REPO/pull/NUM\n  - https://HOST/OWNER/REPO/discussions/NUM\nGot: %s", input)
+	}
+
+	target.Host = strings.ToLower(target.Host)
+	if !hosts.contains(target.Host) {
+		return nil, fmt.Errorf("invalid target format: %s is not a known GitHub host (run 'gh auth login --hostname %s' to add it)", target.Host, target.Host)
+	}
+
+	if err := validateComponents(target.Owner, target.Repo, target.Number); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// parseCommentFragment extracts the comment kind and ID from a permalink
+// fragment. Other fragments are ignored and yield a zero ID.
+func parseCommentFragment(input string) (CommentKind, int) {
+	idx := strings.Index(input, "#")
+	if idx < 0 {
+		return "", 0
+	}
+
+	matches := commentFragmentRegex.FindStringSubmatch(input[idx+1:])
+	if matches == nil {
+		return "", 0
+	}
+	id, err := strconv.Atoi(matches[2])
+	if err != nil || id <= 0 {
+		return "", 0
 	}
 
-	// Try issue URL: https://github.com/OWNER/REPO/issues/NUM
-	if matches := issueURLRegex.FindStringSubmatch(input); matches != nil {
-		owner = matches[1]
-		repo = matches[2]
-		num = matches[3]
-		
-		if err := validateComponents(owner, repo, num); err != nil {
-			return "", "", "", err
+	for kind, prefix := range commentFragments {
+		if prefix == matches[1] {
+			return kind, id
 		}
-		return owner, repo, num, nil
 	}
+	return "", 0
+}
 
-	// Try pull request URL: https://github.com/OWNER/REPO/pull/NUM
-	if matches := pullURLRegex.FindStringSubmatch(input); matches != nil {
-		owner = matches[1]
-		repo = matches[2]
-		num = matches[3]
-		
-		if err := validateComponents(owner, repo, num); err != nil {
-			return "", "", "", err
+// ParseTargetRange parses a target that may name a range of numbers, such as
+// OWNER/REPO#100-120, and returns one target per number in ascending order.
+// Inputs without a range yield a single target.
+func ParseTargetRange(input string, hosts Hosts) ([]*Target, error) {
+	input = strings.TrimSpace(input)
+
+	matches := rangeSuffixRegex.FindStringSubmatch(input)
+	if matches == nil {
+		target, err := ParseTargetForHosts(input, hosts)
+		if err != nil {
+			return nil, err
 		}
-		return owner, repo, num, nil
+		return []*Target{target}, nil
 	}
 
-	return "", "", "", fmt.Errorf("invalid target format. Expected:\n  - OWNER/REPO#NUM\n  - https://github.com/OWNER/REPO/issues/NUM\n  - https://github.com/OWNER/REPO/pull/NUM\nGot: %s", input)
+	first, err := ParseTargetForHosts(matches[1]+matches[2], hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	start, _ := strconv.Atoi(matches[2])
+	end, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid range end: %s", matches[3])
+	}
+	if end < start {
+		return nil, fmt.Errorf("invalid range %s-%s: end is before start", matches[2], matches[3])
+	}
+	if end-start+1 > MaxTargetRange {
+		return nil, fmt.Errorf("range %s-%s is too large (max %d targets)", matches[2], matches[3], MaxTargetRange)
+	}
+
+	targets := make([]*Target, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		target := *first
+		target.Number = strconv.Itoa(n)
+		targets = append(targets, &target)
+	}
+	return targets, nil
 }
 
 // validateComponents performs additional validation on parsed components
@@ -73,7 +270,7 @@ func validateComponents(owner, repo, num string) error {
 	if repo == "" {
 		return fmt.Errorf("repository name cannot be empty")
 	}
-	
+
 	// Validate issue/PR number
 	n, err := strconv.Atoi(num)
 	if err != nil {
@@ -82,7 +279,7 @@ func validateComponents(owner, repo, num string) error {
 	if n <= 0 {
 		return fmt.Errorf("issue/PR number must be positive, got: %d", n)
 	}
-	
+
 	// Additional GitHub username/org validation
 	if len(owner) > 39 {
 		return fmt.Errorf("owner name too long (max 39 characters): %s", owner)
@@ -90,6 +287,6 @@ func validateComponents(owner, repo, num string) error {
 	if len(repo) > 100 {
 		return fmt.Errorf("repository name too long (max 100 characters): %s", repo)
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
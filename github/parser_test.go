@@ -1,6 +1,7 @@
 package github
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -211,6 +212,106 @@ func TestParseTargetWithWhitespace(t *testing.T) {
 	}
 }
 
+func TestParseTargetForHosts(t *testing.T) {
+	hosts := Hosts{Default: DefaultHost, Known: []string{"ghe.example.com"}}
+
+	tests := []struct {
+		name        string
+		input       string
+		want        Target
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:  "short form uses default host",
+			input: "octocat/Hello-World#1",
+			want:  Target{Host: "github.com", Owner: "octocat", Repo: "Hello-World", Number: "1"},
+		},
+		{
+			name:  "host short form",
+			input: "ghe.example.com/team/app#42",
+			want:  Target{Host: "ghe.example.com", Owner: "team", Repo: "app", Number: "42"},
+		},
+		{
+			name:  "enterprise issue URL",
+			input: "https://ghe.example.com/team/app/issues/7",
+			want:  Target{Host: "ghe.example.com", Owner: "team", Repo: "app", Number: "7"},
+		},
+		{
+			name:  "enterprise pull URL with mixed case host",
+			input: "https://GHE.example.com/team/app/pull/8/files",
+			want:  Target{Host: "ghe.example.com", Owner: "team", Repo: "app", Number: "8"},
+		},
+		{
+			name:  "github.com URL",
+			input: "https://github.com/octocat/Hello-World/issues/3",
+			want:  Target{Host: "github.com", Owner: "octocat", Repo: "Hello-World", Number: "3"},
+		},
+		{
+			name:        "unknown host",
+			input:       "https://gitlab.com/octocat/Hello-World/issues/123",
+			wantErr:     true,
+			errContains: "not a known GitHub host",
+		},
+		{
+			name:        "unknown host short form",
+			input:       "git.example.org/team/app#1",
+			wantErr:     true,
+			errContains: "invalid target format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTargetForHosts(tt.input, hosts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTargetForHosts() expected error, got %+v", got)
+				}
+				if !containsString(err.Error(), tt.errContains) {
+					t.Errorf("ParseTargetForHosts() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTargetForHosts() unexpected error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseTargetForHosts() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTargetForHosts_DefaultHost(t *testing.T) {
+	got, err := ParseTargetForHosts("team/app#5", Hosts{Default: "ghe.example.com"})
+	if err != nil {
+		t.Fatalf("ParseTargetForHosts() unexpected error = %v", err)
+	}
+	if got.Host != "ghe.example.com" {
+		t.Errorf("Host = %q, want ghe.example.com", got.Host)
+	}
+}
+
+func TestTarget_String(t *testing.T) {
+	tests := []struct {
+		target Target
+		want   string
+	}{
+		{Target{Host: "github.com", Owner: "o", Repo: "r", Number: "1"}, "o/r#1"},
+		{Target{Owner: "o", Repo: "r", Number: "1"}, "o/r#1"},
+		{Target{Host: "ghe.example.com", Owner: "o", Repo: "r", Number: "1"}, "ghe.example.com/o/r#1"},
+		{Target{Host: "github.com", Owner: "o", Repo: "r", Number: "1", Kind: TargetDiscussion}, "https://github.com/o/r/discussions/1"},
+		{Target{Host: "ghe.example.com", Owner: "o", Repo: "r", Number: "1", Kind: TargetDiscussion}, "https://ghe.example.com/o/r/discussions/1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.target.String(); got != tt.want {
+			t.Errorf("Target.String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
 // Helper function to check if a string contains a substring
 func containsString(s, substr string) bool {
 	return len(substr) == 0 || (len(s) >= len(substr) && stringContains(s, substr))
@@ -223,4 +324,123 @@ func stringContains(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+func TestParseTargetRange(t *testing.T) {
+	hosts := Hosts{Default: DefaultHost, Known: []string{"ghe.example.com"}}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{name: "single target", input: "owner/repo#7", want: []string{"owner/repo#7"}},
+		{name: "url", input: "https://github.com/owner/repo/pull/7", want: []string{"owner/repo#7"}},
+		{name: "range", input: "owner/repo#100-103", want: []string{"owner/repo#100", "owner/repo#101", "owner/repo#102", "owner/repo#103"}},
+		{name: "single number range", input: "owner/repo#5-5", want: []string{"owner/repo#5"}},
+		{name: "host range", input: "ghe.example.com/owner/repo#1-2", want: []string{"ghe.example.com/owner/repo#1", "ghe.example.com/owner/repo#2"}},
+		{name: "reversed range", input: "owner/repo#10-9", wantErr: "end is before start"},
+		{name: "range too large", input: "owner/repo#1-5000", wantErr: "too large"},
+		{name: "zero start", input: "owner/repo#0-3", wantErr: "must be positive"},
+		{name: "unknown host range", input: "gitlab.com/owner/repo#1-2", wantErr: "not a known GitHub host"},
+		{name: "invalid", input: "owner/repo", wantErr: "invalid target format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ParseTargetRange(tt.input, hosts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseTargetRange(%q) error = %v, want error containing %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTargetRange(%q) unexpected error: %v", tt.input, err)
+			}
+			if len(targets) != len(tt.want) {
+				t.Fatalf("ParseTargetRange(%q) returned %d targets, want %d", tt.input, len(targets), len(tt.want))
+			}
+			for i, target := range targets {
+				if got := target.String(); got != tt.want[i] {
+					t.Errorf("target %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTargetForHosts_Discussion(t *testing.T) {
+	hosts := Hosts{Default: DefaultHost, Known: []string{"ghe.example.com"}}
+
+	tests := []struct {
+		input string
+		host  string
+	}{
+		{"https://github.com/owner/repo/discussions/45", "github.com"},
+		{"https://github.com/owner/repo/discussions/45#discussioncomment-99", "github.com"},
+		{"https://ghe.example.com/owner/repo/discussions/45", "ghe.example.com"},
+	}
+
+	for _, tt := range tests {
+		target, err := ParseTargetForHosts(tt.input, hosts)
+		if err != nil {
+			t.Fatalf("ParseTargetForHosts(%q) failed: %v", tt.input, err)
+		}
+		if !target.IsDiscussion() || target.Host != tt.host || target.Owner != "owner" || target.Repo != "repo" || target.Number != "45" {
+			t.Errorf("ParseTargetForHosts(%q) = %+v", tt.input, target)
+		}
+
+		// The string form parses back to the same discussion
+		again, err := ParseTargetForHosts(target.String(), hosts)
+		if err != nil || *again != *target {
+			t.Errorf("Round trip of %q = %+v, %v", target.String(), again, err)
+		}
+	}
+
+	target, err := ParseTargetForHosts("owner/repo#45", hosts)
+	if err != nil || target.IsDiscussion() {
+		t.Errorf("Short form targets should not be discussions: %+v, %v", target, err)
+	}
+}
+
+func TestParseTargetForHosts_CommentPermalink(t *testing.T) {
+	hosts := Hosts{Default: DefaultHost, Known: []string{"ghe.example.com"}}
+
+	tests := []struct {
+		input    string
+		wantKind CommentKind
+		wantID   int
+		wantStr  string
+	}{
+		{"https://github.com/o/r/issues/12#issuecomment-123456", CommentKindIssue, 123456, "https://github.com/o/r/issues/12#issuecomment-123456"},
+		{"https://github.com/o/r/pull/40#issuecomment-77", CommentKindIssue, 77, "https://github.com/o/r/issues/40#issuecomment-77"},
+		{"https://github.com/o/r/pull/40#discussion_r98765", CommentKindReviewComment, 98765, "https://github.com/o/r/pull/40#discussion_r98765"},
+		{"https://github.com/o/r/pull/40/files#discussion_r98765", CommentKindReviewComment, 98765, "https://github.com/o/r/pull/40#discussion_r98765"},
+		{"https://github.com/o/r/pull/40#pullrequestreview-555", CommentKindReview, 555, "https://github.com/o/r/pull/40#pullrequestreview-555"},
+		{"https://ghe.example.com/o/r/issues/3#issuecomment-9", CommentKindIssue, 9, "https://ghe.example.com/o/r/issues/3#issuecomment-9"},
+		{"https://github.com/o/r/issues/12#event-42", "", 0, "o/r#12"},
+		{"https://github.com/o/r/issues/12", "", 0, "o/r#12"},
+	}
+
+	for _, tt := range tests {
+		target, err := ParseTargetForHosts(tt.input, hosts)
+		if err != nil {
+			t.Fatalf("ParseTargetForHosts(%q) failed: %v", tt.input, err)
+		}
+		if target.CommentKind != tt.wantKind || target.CommentID != tt.wantID {
+			t.Errorf("ParseTargetForHosts(%q) comment = %s/%d, want %s/%d", tt.input, target.CommentKind, target.CommentID, tt.wantKind, tt.wantID)
+		}
+		if target.IsComment() != (tt.wantID != 0) {
+			t.Errorf("IsComment() = %v for %q", target.IsComment(), tt.input)
+		}
+		if got := target.String(); got != tt.wantStr {
+			t.Errorf("String() = %q, want %q", got, tt.wantStr)
+		}
+
+		again, err := ParseTargetForHosts(target.String(), hosts)
+		if err != nil || *again != *target {
+			t.Errorf("Round trip of %q = %+v, %v", target.String(), again, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// reviewComment is the API shape of an inline pull request review comment
+type reviewComment struct {
+	Comment
+	OriginalLine int `json:"original_line"`
+}
+
+// review is the API shape of a pull request review
+type review struct {
+	ID          int       `json:"id"`
+	Body        string    `json:"body"`
+	BodyHTML    string    `json:"body_html"`
+	User        User      `json:"user"`
+	State       string    `json:"state"`
+	SubmittedAt time.Time `json:"submitted_at"`
+
+	AuthorAssociation string `json:"author_association"`
+}
+
+// FetchReviewComments retrieves all inline review comments on a pull request.
+// Comments on lines that no longer exist in the diff keep their original line.
+func (c *Client) FetchReviewComments(owner, repo, num string) ([]*Comment, error) {
+	if owner == "" || repo == "" || num == "" {
+		return nil, fmt.Errorf("owner, repo, and number are required")
+	}
+
+	apiPath := fmt.Sprintf("repos/%s/%s/pulls/%s/comments", owner, repo, num)
+	output, err := c.get(apiPath, true, fmt.Sprintf("pull request %s not found in %s/%s", num, owner, repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []reviewComment
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	comments := make([]*Comment, 0, len(raw))
+	for i := range raw {
+		comments = append(comments, raw[i].toComment())
+	}
+
+	return comments, nil
+}
+
+// toComment maps an inline review comment onto the Comment type
+func (r reviewComment) toComment() *Comment {
+	comment := r.Comment
+	comment.Kind = CommentKindReviewComment
+	if comment.Line == 0 {
+		comment.Line = r.OriginalLine
+	}
+	return &comment
+}
+
+// FetchReviewComment retrieves a single inline review comment by ID
+func (c *Client) FetchReviewComment(owner, repo string, id int) (*Comment, error) {
+	if owner == "" || repo == "" || id <= 0 {
+		return nil, fmt.Errorf("owner, repo, and comment ID are required")
+	}
+
+	apiPath := fmt.Sprintf("repos/%s/%s/pulls/comments/%d", owner, repo, id)
+	output, err := c.get(apiPath, false, fmt.Sprintf("review comment %d not found in %s/%s", id, owner, repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw reviewComment
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	return raw.toComment(), nil
+}
+
+// FetchReview retrieves the summary body of a single pull request review as
+// a comment. A review without a body yields no comments.
+func (c *Client) FetchReview(owner, repo, num string, id int) ([]*Comment, error) {
+	if owner == "" || repo == "" || num == "" || id <= 0 {
+		return nil, fmt.Errorf("owner, repo, number, and review ID are required")
+	}
+
+	apiPath := fmt.Sprintf("repos/%s/%s/pulls/%s/reviews/%d", owner, repo, num, id)
+	output, err := c.get(apiPath, false, fmt.Sprintf("review %d not found on pull request %s in %s/%s", id, num, owner, repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw review
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	return reviewsToComments([]review{raw}), nil
+}
+
+// FetchReviews retrieves the summary bodies of all reviews on a pull request
+// as comments. Reviews without a body, such as bare approvals, are skipped.
+func (c *Client) FetchReviews(owner, repo, num string) ([]*Comment, error) {
+	if owner == "" || repo == "" || num == "" {
+		return nil, fmt.Errorf("owner, repo, and number are required")
+	}
+
+	apiPath := fmt.Sprintf("repos/%s/%s/pulls/%s/reviews", owner, repo, num)
+	output, err := c.get(apiPath, true, fmt.Sprintf("pull request %s not found in %s/%s", num, owner, repo))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []review
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	return reviewsToComments(raw), nil
+}
+
+// reviewsToComments maps reviews with a body onto the Comment type. Reviews
+// are dated by submission; the REST API gives pending reviews no date at
+// all, so they are dated now and sort after the submitted ones.
+func reviewsToComments(reviews []review) []*Comment {
+	now := time.Now()
+	comments := make([]*Comment, 0, len(reviews))
+	for _, r := range reviews {
+		if r.Body == "" {
+			continue
+		}
+		if r.SubmittedAt.IsZero() {
+			r.SubmittedAt = now
+		}
+		comments = append(comments, &Comment{
+			ID:        r.ID,
+			Kind:      CommentKindReview,
+			Body:      r.Body,
+			BodyHTML:  r.BodyHTML,
+			User:      r.User,
+			CreatedAt: r.SubmittedAt,
+			UpdatedAt: r.SubmittedAt,
+
+			AuthorAssociation: r.AuthorAssociation,
+		})
+	}
+	return comments
+}
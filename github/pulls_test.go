@@ -0,0 +1,99 @@
+package github
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestIssue_IsPullRequest(t *testing.T) {
+	var issue Issue
+	if err := json.Unmarshal([]byte(`{"number": 1, "title": "Issue"}`), &issue); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if issue.IsPullRequest() {
+		t.Error("Plain issue reported as pull request")
+	}
+
+	var pr Issue
+	if err := json.Unmarshal([]byte(`{"number": 2, "pull_request": {"url": "https://api.github.com/repos/o/r/pulls/2"}}`), &pr); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !pr.IsPullRequest() {
+		t.Error("Pull request not detected")
+	}
+}
+
+func TestReviewComment_Unmarshal(t *testing.T) {
+	payload := `[
+		{"id": 10, "body": "![before](https://example.com/a.png)", "path": "ui/button.tsx", "line": 42, "original_line": 40, "user": {"login": "designer"}, "created_at": "2024-01-02T00:00:00Z"},
+		{"id": 11, "body": "outdated", "path": "ui/old.tsx", "line": null, "original_line": 7, "user": {"login": "designer"}, "created_at": "2024-01-03T00:00:00Z"}
+	]`
+
+	var raw []reviewComment
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if raw[0].Path != "ui/button.tsx" || raw[0].Line != 42 {
+		t.Errorf("raw[0] path/line = %s:%d, want ui/button.tsx:42", raw[0].Path, raw[0].Line)
+	}
+	if raw[1].Line != 0 || raw[1].OriginalLine != 7 {
+		t.Errorf("raw[1] line/original = %d/%d, want 0/7", raw[1].Line, raw[1].OriginalLine)
+	}
+	if raw[0].User.Login != "designer" {
+		t.Errorf("raw[0] author = %q, want designer", raw[0].User.Login)
+	}
+}
+
+func TestReviewsToComments(t *testing.T) {
+	submitted := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	reviews := []review{
+		{ID: 1, Body: "", State: "APPROVED", SubmittedAt: submitted},
+		{ID: 2, Body: "After: ![after](https://example.com/after.png)", User: User{Login: "designer"}, State: "COMMENTED", SubmittedAt: submitted},
+		{ID: 3, Body: "draft", State: "PENDING"},
+	}
+
+	comments := reviewsToComments(reviews)
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comments (empty reviews skipped), got %d", len(comments))
+	}
+	if comments[0].ID != 2 || comments[0].Kind != CommentKindReview {
+		t.Errorf("Comment = %+v, want review 2", comments[0])
+	}
+	if !comments[0].CreatedAt.Equal(submitted) {
+		t.Errorf("CreatedAt = %v, want submitted time %v", comments[0].CreatedAt, submitted)
+	}
+	if pending := comments[1]; !pending.CreatedAt.After(submitted) || !pending.UpdatedAt.Equal(pending.CreatedAt) {
+		t.Errorf("Pending review dated %v/%v, want after the submitted review", pending.CreatedAt, pending.UpdatedAt)
+	}
+}
+
+func TestClient_FetchReviews_ValidationErrors(t *testing.T) {
+	client := NewClient(30 * time.Second)
+
+	if _, err := client.FetchReviews("", "repo", "1"); err == nil || !containsString(err.Error(), "required") {
+		t.Errorf("FetchReviews error = %v, want error containing 'required'", err)
+	}
+	if _, err := client.FetchReviewComments("owner", "", "1"); err == nil || !containsString(err.Error(), "required") {
+		t.Errorf("FetchReviewComments error = %v, want error containing 'required'", err)
+	}
+	if _, err := client.FetchReviewComment("owner", "repo", 0); err == nil || !containsString(err.Error(), "required") {
+		t.Errorf("FetchReviewComment error = %v, want error containing 'required'", err)
+	}
+	if _, err := client.FetchReview("owner", "repo", "1", 0); err == nil || !containsString(err.Error(), "required") {
+		t.Errorf("FetchReview error = %v, want error containing 'required'", err)
+	}
+	if _, err := client.FetchComment("owner", "repo", -1); err == nil || !containsString(err.Error(), "required") {
+		t.Errorf("FetchComment error = %v, want error containing 'required'", err)
+	}
+}
+
+func TestReviewComment_ToComment(t *testing.T) {
+	raw := reviewComment{Comment: Comment{ID: 11, Path: "ui/old.tsx"}, OriginalLine: 7}
+
+	comment := raw.toComment()
+	if comment.Kind != CommentKindReviewComment || comment.Line != 7 || comment.Path != "ui/old.tsx" {
+		t.Errorf("toComment() = %+v, want review comment on ui/old.tsx:7", comment)
+	}
+}
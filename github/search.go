@@ -0,0 +1,93 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxSearchResults is the most results the search API returns for a query
+const MaxSearchResults = 1000
+
+// searchPageSize is the number of results requested per search page
+const searchPageSize = 100
+
+// searchResponse is the API shape of an issue search page
+type searchResponse struct {
+	TotalCount        int          `json:"total_count"`
+	IncompleteResults bool         `json:"incomplete_results"`
+	Items             []searchItem `json:"items"`
+}
+
+// searchItem is a single issue or pull request in a search page
+type searchItem struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// SearchIssues runs an issue and pull request search, such as
+// "repo:org/app label:ui is:open", and returns up to limit matching targets
+// in the order the API ranks them.
+func (c *Client) SearchIssues(query string, limit int) ([]*Target, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("search limit must be positive, got: %d", limit)
+	}
+	if limit > MaxSearchResults {
+		limit = MaxSearchResults
+	}
+
+	perPage := searchPageSize
+	if limit < perPage {
+		perPage = limit
+	}
+
+	var targets []*Target
+	for page := 1; len(targets) < limit; page++ {
+		apiPath := fmt.Sprintf("search/issues?q=%s&per_page=%d&page=%d", url.QueryEscape(query), perPage, page)
+		output, err := c.get(apiPath, false, fmt.Sprintf("search failed for query: %s", query))
+		if err != nil {
+			return nil, err
+		}
+
+		found, total, err := c.parseSearchPage(output)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, found...)
+
+		if len(found) < perPage || page*perPage >= total || page*perPage >= MaxSearchResults {
+			break
+		}
+	}
+
+	if len(targets) > limit {
+		targets = targets[:limit]
+	}
+	return targets, nil
+}
+
+// parseSearchPage converts a search page into targets on the client's host
+// and returns the total number of matches reported by the API
+func (c *Client) parseSearchPage(body []byte) ([]*Target, int, error) {
+	var response searchResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	hosts := Hosts{Default: c.host, Known: []string{c.host}}
+	targets := make([]*Target, 0, len(response.Items))
+	for _, item := range response.Items {
+		target, err := ParseTargetForHosts(item.HTMLURL, hosts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unexpected search result %s: %w", item.HTMLURL, err)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, response.TotalCount, nil
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_ParseSearchPage(t *testing.T) {
+	body := []byte(`{
+		"total_count": 3,
+		"incomplete_results": false,
+		"items": [
+			{"number": 12, "html_url": "https://github.com/org/app/issues/12"},
+			{"number": 40, "html_url": "https://github.com/org/app/pull/40"}
+		]
+	}`)
+
+	client := NewClient(time.Second)
+	targets, total, err := client.parseSearchPage(body)
+	if err != nil {
+		t.Fatalf("parseSearchPage failed: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	if len(targets) != 2 || targets[0].String() != "org/app#12" || targets[1].String() != "org/app#40" {
+		t.Errorf("targets = %v", targets)
+	}
+}
+
+func TestClient_ParseSearchPage_EnterpriseHost(t *testing.T) {
+	body := []byte(`{"total_count": 1, "items": [{"number": 3, "html_url": "https://ghe.example.com/team/app/issues/3"}]}`)
+
+	client := NewClientForHost("ghe.example.com", time.Second)
+	targets, _, err := client.parseSearchPage(body)
+	if err != nil {
+		t.Fatalf("parseSearchPage failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0].Host != "ghe.example.com" {
+		t.Errorf("targets = %v, want one target on ghe.example.com", targets)
+	}
+}
+
+func TestClient_ParseSearchPage_Errors(t *testing.T) {
+	client := NewClient(time.Second)
+
+	if _, _, err := client.parseSearchPage([]byte(`not json`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+
+	body := []byte(`{"total_count": 1, "items": [{"number": 3, "html_url": "https://other.example.com/team/app/issues/3"}]}`)
+	if _, _, err := client.parseSearchPage(body); err == nil || !strings.Contains(err.Error(), "unexpected search result") {
+		t.Errorf("Expected unexpected search result error, got: %v", err)
+	}
+}
+
+func TestClient_SearchIssues_ValidationErrors(t *testing.T) {
+	client := NewClient(time.Second)
+
+	tests := []struct {
+		name    string
+		query   string
+		limit   int
+		wantErr string
+	}{
+		{"empty query", "  ", 10, "search query cannot be empty"},
+		{"zero limit", "repo:org/app", 0, "search limit must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SearchIssues(tt.query, tt.limit)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SearchIssues error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package github
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// threadQuery fetches an issue or pull request with its comments, reviews
+// and review threads. Each connection has its own cursor and is dropped from
+// later pages through its $with* flag once exhausted, so a thread costs one
+// request per page of its longest connection. Review threads with more
+// comments than fit on a page are finished with reviewThreadQuery.
+const threadQuery = `query($owner: String!, $repo: String!, $number: Int!,
+  $commentsCursor: String, $reviewsCursor: String, $threadsCursor: String,
+  $withComments: Boolean!, $withReviews: Boolean!, $withThreads: Boolean!) {
+  repository(owner: $owner, name: $repo) {
+    issueOrPullRequest(number: $number) {
+      __typename
+      ... on Issue {
+        number
+        title
+        body
+        bodyHTML
+        state
+        createdAt
+        updatedAt
+        author { login }
+        authorAssociation
+        comments(first: 100, after: $commentsCursor) @include(if: $withComments) {
+          ...threadComments
+        }
+      }
+      ... on PullRequest {
+        number
+        title
+        body
+        bodyHTML
+        state
+        url
+        createdAt
+        updatedAt
+        author { login }
+        authorAssociation
+        comments(first: 100, after: $commentsCursor) @include(if: $withComments) {
+          ...threadComments
+        }
+        reviews(first: 100, after: $reviewsCursor) @include(if: $withReviews) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            databaseId
+            body
+            bodyHTML
+            createdAt
+            updatedAt
+            submittedAt
+            author { login }
+            authorAssociation
+          }
+        }
+        reviewThreads(first: 50, after: $threadsCursor) @include(if: $withThreads) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            id
+            comments(first: 100) {
+              ...reviewThreadComments
+            }
+          }
+        }
+      }
+    }
+  }
+}
+
+fragment threadComments on IssueCommentConnection {
+  pageInfo { hasNextPage endCursor }
+  nodes {
+    databaseId
+    body
+    bodyHTML
+    createdAt
+    updatedAt
+    author { login }
+    authorAssociation
+  }
+}
+` + reviewThreadCommentsFragment
+
+// reviewThreadQuery fetches the next page of comments in a review thread
+const reviewThreadQuery = `query($id: ID!, $cursor: String) {
+  node(id: $id) {
+    ... on PullRequestReviewThread {
+      comments(first: 100, after: $cursor) {
+        ...reviewThreadComments
+      }
+    }
+  }
+}
+` + reviewThreadCommentsFragment
+
+// reviewThreadCommentsFragment selects a page of inline review comments
+const reviewThreadCommentsFragment = `
+fragment reviewThreadComments on PullRequestReviewCommentConnection {
+  pageInfo { hasNextPage endCursor }
+  nodes {
+    databaseId
+    body
+    bodyHTML
+    path
+    line
+    originalLine
+    createdAt
+    updatedAt
+    author { login }
+    authorAssociation
+  }
+}`
+
+// pageInfo is the GraphQL pagination state of a connection
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// more reports whether another page follows
+func (p pageInfo) more() bool {
+	return p.HasNextPage && p.EndCursor != ""
+}
+
+// threadComment is the GraphQL shape of an issue comment, review or inline
+// review comment. Fields a type lacks are left empty.
+type threadComment struct {
+	DatabaseID   int            `json:"databaseId"`
+	Body         string         `json:"body"`
+	BodyHTML     string         `json:"bodyHTML"`
+	Path         string         `json:"path"`
+	Line         int            `json:"line"`
+	OriginalLine int            `json:"originalLine"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	SubmittedAt  time.Time      `json:"submittedAt"`
+	Author       *graphQLAuthor `json:"author"`
+	// AuthorAssociation uses the same values as the REST API
+	AuthorAssociation string `json:"authorAssociation"`
+}
+
+// threadConnection is a page of a GraphQL comment or review connection
+type threadConnection struct {
+	PageInfo pageInfo        `json:"pageInfo"`
+	Nodes    []threadComment `json:"nodes"`
+}
+
+// threadResponse is the GraphQL data for threadQuery
+type threadResponse struct {
+	Repository struct {
+		IssueOrPullRequest *struct {
+			Typename  string         `json:"__typename"`
+			Number    int            `json:"number"`
+			Title     string         `json:"title"`
+			Body      string         `json:"body"`
+			BodyHTML  string         `json:"bodyHTML"`
+			State     string         `json:"state"`
+			URL       string         `json:"url"`
+			CreatedAt time.Time      `json:"createdAt"`
+			UpdatedAt time.Time      `json:"updatedAt"`
+			Author    *graphQLAuthor `json:"author"`
+
+			AuthorAssociation string `json:"authorAssociation"`
+
+			Comments      threadConnection `json:"comments"`
+			Reviews       threadConnection `json:"reviews"`
+			ReviewThreads struct {
+				PageInfo pageInfo `json:"pageInfo"`
+				Nodes    []struct {
+					ID       string           `json:"id"`
+					Comments threadConnection `json:"comments"`
+				} `json:"nodes"`
+			} `json:"reviewThreads"`
+		} `json:"issueOrPullRequest"`
+	} `json:"repository"`
+}
+
+// reviewThreadResponse is the GraphQL data for reviewThreadQuery
+type reviewThreadResponse struct {
+	Node *struct {
+		Comments threadConnection `json:"comments"`
+	} `json:"node"`
+}
+
+// FetchThread retrieves an issue or pull request together with its
+// conversation comments and, for pull requests, its inline review comments
+// and review bodies through paginated GraphQL queries. The result matches
+// FetchIssue, FetchComments, FetchReviewComments and FetchReviews combined,
+// in that order, while costing a single request for most threads.
+//
+// A client with a cache keeps the result and, on later calls, reuses it
+// while a conditional REST request shows the thread has not been updated.
+func (c *Client) FetchThread(owner, repo, num string) (*Issue, []*Comment, error) {
+	if owner == "" || repo == "" || num == "" {
+		return nil, nil, fmt.Errorf("owner, repo, and number are required")
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid issue/PR number: %s", num)
+	}
+	if issue, comments, ok := c.cachedThread(owner, repo, num); ok {
+		return issue, comments, nil
+	}
+
+	issue, comments, err := c.queryThread(owner, repo, num, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.storeThread(owner, repo, num, issue, comments)
+	return issue, comments, nil
+}
+
+// queryThread fetches a thread through threadQuery, following each
+// connection's cursor until all of them are exhausted
+func (c *Client) queryThread(owner, repo, num string, number int) (*Issue, []*Comment, error) {
+	notFound := fmt.Sprintf("issue/PR %s not found in %s/%s", num, owner, repo)
+	variables := map[string]interface{}{
+		"owner":          owner,
+		"repo":           repo,
+		"number":         number,
+		"commentsCursor": nil,
+		"reviewsCursor":  nil,
+		"threadsCursor":  nil,
+		"withComments":   true,
+		"withReviews":    true,
+		"withThreads":    true,
+	}
+
+	var issue *Issue
+	var comments, reviewComments, reviews []*Comment
+	for {
+		var page threadResponse
+		if err := c.graphQL(threadQuery, variables, &page, notFound); err != nil {
+			return nil, nil, err
+		}
+
+		thread := page.Repository.IssueOrPullRequest
+		if thread == nil {
+			return nil, nil, fmt.Errorf("%s", notFound)
+		}
+		if issue == nil {
+			issue = &Issue{
+				Number:    thread.Number,
+				Title:     thread.Title,
+				Body:      thread.Body,
+				BodyHTML:  thread.BodyHTML,
+				State:     restState(thread.State),
+				User:      thread.Author.user(),
+				CreatedAt: thread.CreatedAt,
+				UpdatedAt: thread.UpdatedAt,
+
+				AuthorAssociation: thread.AuthorAssociation,
+			}
+			if thread.Typename == "PullRequest" {
+				issue.PullRequest = &struct {
+					URL string `json:"url"`
+				}{URL: thread.URL}
+			}
+		}
+
+		for _, node := range thread.Comments.Nodes {
+			comments = append(comments, node.toComment(CommentKindIssue))
+		}
+		for _, node := range thread.Reviews.Nodes {
+			if node.Body == "" {
+				continue
+			}
+			reviews = append(reviews, node.toComment(CommentKindReview))
+		}
+		for _, reviewThread := range thread.ReviewThreads.Nodes {
+			nodes, err := c.reviewThreadComments(reviewThread.ID, reviewThread.Comments, notFound)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, node := range nodes {
+				reviewComments = append(reviewComments, node.toComment(CommentKindReviewComment))
+			}
+		}
+
+		more := false
+		for _, conn := range []struct {
+			flag, cursor string
+			info         pageInfo
+		}{
+			{"withComments", "commentsCursor", thread.Comments.PageInfo},
+			{"withReviews", "reviewsCursor", thread.Reviews.PageInfo},
+			{"withThreads", "threadsCursor", thread.ReviewThreads.PageInfo},
+		} {
+			if variables[conn.flag] == false {
+				continue
+			}
+			if !conn.info.more() {
+				variables[conn.flag] = false
+				continue
+			}
+			variables[conn.cursor] = conn.info.EndCursor
+			more = true
+		}
+		if !more {
+			break
+		}
+	}
+
+	comments = append(comments, reviewComments...)
+	comments = append(comments, reviews...)
+	return issue, comments, nil
+}
+
+// reviewThreadComments returns the comments of a review thread, following
+// its cursor from the first page until the thread is exhausted
+func (c *Client) reviewThreadComments(id string, page threadConnection, notFound string) ([]threadComment, error) {
+	nodes := page.Nodes
+	for page.PageInfo.more() && id != "" {
+		var next reviewThreadResponse
+		variables := map[string]interface{}{"id": id, "cursor": page.PageInfo.EndCursor}
+		if err := c.graphQL(reviewThreadQuery, variables, &next, notFound); err != nil {
+			return nil, err
+		}
+		if next.Node == nil {
+			break
+		}
+		page = next.Node.Comments
+		nodes = append(nodes, page.Nodes...)
+	}
+	return nodes, nil
+}
+
+// toComment maps a GraphQL comment or review onto the Comment type. Reviews
+// are dated by submission, falling back to creation for pending reviews
+// that have not been submitted yet. Inline comments on lines that no longer
+// exist in the diff keep their original line, as in the REST mapping.
+func (t threadComment) toComment(kind CommentKind) *Comment {
+	comment := &Comment{
+		ID:        t.DatabaseID,
+		Kind:      kind,
+		Body:      t.Body,
+		BodyHTML:  t.BodyHTML,
+		User:      t.Author.user(),
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+		Path:      t.Path,
+		Line:      t.Line,
+
+		AuthorAssociation: t.AuthorAssociation,
+	}
+	if kind == CommentKindReview && !t.SubmittedAt.IsZero() {
+		comment.CreatedAt = t.SubmittedAt
+		comment.UpdatedAt = t.SubmittedAt
+	}
+	if comment.Line == 0 {
+		comment.Line = t.OriginalLine
+	}
+	return comment
+}
+
+// restState converts a GraphQL issue or pull request state into the REST
+// value. Merged pull requests are closed in the REST API.
+func restState(state string) string {
+	if state == "MERGED" {
+		return "closed"
+	}
+	return strings.ToLower(state)
+}
@@ -0,0 +1,268 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kojikawamura/gh-ccimg/httpcache"
+)
+
+func TestClient_FetchThread_PullRequest(t *testing.T) {
+	var requests []map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" || r.Method != http.MethodPost {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode GraphQL request: %v", err)
+		}
+		requests = append(requests, req.Variables)
+
+		if len(requests) == 1 {
+			w.Write([]byte(`{"data":{"repository":{"issueOrPullRequest":{
+				"__typename": "PullRequest", "number": 8, "title": "Redesign", "body": "![a](https://example.com/a.png)",
+				"bodyHTML": "<p>a</p>", "state": "MERGED", "url": "https://github.com/octo/repo/pull/8",
+				"createdAt": "2024-05-01T09:00:00Z", "author": {"login": "dev"}, "authorAssociation": "MEMBER",
+				"comments": {"pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+					{"databaseId": 1, "body": "first", "createdAt": "2024-05-01T10:00:00Z", "author": {"login": "pm"}}
+				]},
+				"reviews": {"pageInfo": {"hasNextPage": false, "endCursor": "r1"}, "nodes": [
+					{"databaseId": 20, "body": "", "submittedAt": "2024-05-02T09:00:00Z", "author": {"login": "lead"}},
+					{"databaseId": 21, "body": "see ![b](https://example.com/b.png)", "submittedAt": "2024-05-02T10:00:00Z", "author": {"login": "lead"}}
+				]},
+				"reviewThreads": {"pageInfo": {"hasNextPage": false, "endCursor": "t1"}, "nodes": [
+					{"comments": {"nodes": [
+						{"databaseId": 30, "body": "inline", "path": "ui/button.tsx", "line": null, "originalLine": 7, "createdAt": "2024-05-02T09:30:00Z", "author": null}
+					]}}
+				]}
+			}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"issueOrPullRequest":{
+			"__typename": "PullRequest", "number": 8, "title": "Redesign", "body": "ignored",
+			"comments": {"pageInfo": {"hasNextPage": false, "endCursor": "c2"}, "nodes": [
+				{"databaseId": 2, "body": "second", "createdAt": "2024-05-03T10:00:00Z", "author": {"login": "qa"}}
+			]}
+		}}}}`))
+	})
+
+	issue, comments, err := client.FetchThread("octo", "repo", "8")
+	if err != nil {
+		t.Fatalf("FetchThread failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Made %d GraphQL requests, want 2", len(requests))
+	}
+	second := requests[1]
+	if second["commentsCursor"] != "c1" || second["withComments"] != true {
+		t.Errorf("Second page variables = %v, want comments after c1", second)
+	}
+	if second["withReviews"] != false || second["withThreads"] != false {
+		t.Errorf("Second page variables = %v, want exhausted reviews and threads skipped", second)
+	}
+
+	if !issue.IsPullRequest() || issue.Number != 8 || issue.State != "closed" || issue.User.Login != "dev" || issue.Body != "![a](https://example.com/a.png)" {
+		t.Errorf("Issue = %+v", issue)
+	}
+
+	wantIDs := []int{1, 2, 30, 21}
+	wantKinds := []CommentKind{CommentKindIssue, CommentKindIssue, CommentKindReviewComment, CommentKindReview}
+	if len(comments) != len(wantIDs) {
+		t.Fatalf("Got %d comments, want %d", len(comments), len(wantIDs))
+	}
+	for i, comment := range comments {
+		if comment.ID != wantIDs[i] || comment.Kind != wantKinds[i] {
+			t.Errorf("comments[%d] = %d/%s, want %d/%s", i, comment.ID, comment.Kind, wantIDs[i], wantKinds[i])
+		}
+	}
+
+	inline := comments[2]
+	if inline.Path != "ui/button.tsx" || inline.Line != 7 || inline.User.Login != "" {
+		t.Errorf("Inline comment = %+v, want ui/button.tsx:7 by a deleted user", inline)
+	}
+	review := comments[3]
+	if review.User.Login != "lead" || review.CreatedAt.IsZero() {
+		t.Errorf("Review = %+v, want lead dated by submission", review)
+	}
+}
+
+func TestClient_FetchThread_Issue(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"issueOrPullRequest":{
+			"__typename": "Issue", "number": 3, "title": "Bug", "body": "body", "state": "OPEN",
+			"comments": {"pageInfo": {"hasNextPage": false, "endCursor": null}, "nodes": [
+				{"databaseId": 5, "body": "comment", "author": {"login": "reporter"}}
+			]}
+		}}}}`))
+	})
+
+	issue, comments, err := client.FetchThread("octo", "repo", "3")
+	if err != nil {
+		t.Fatalf("FetchThread failed: %v", err)
+	}
+	if issue.IsPullRequest() || issue.State != "open" {
+		t.Errorf("Issue = %+v, want open plain issue", issue)
+	}
+	if len(comments) != 1 || comments[0].ID != 5 || comments[0].Kind != CommentKindIssue {
+		t.Errorf("Comments = %+v, want the single issue comment", comments)
+	}
+}
+
+func TestClient_FetchThread_Errors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"issueOrPullRequest":null}},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to an issue or pull request"}]}`))
+	})
+
+	_, _, err := client.FetchThread("octo", "repo", "7")
+	if err == nil || err.Error() != "issue/PR 7 not found in octo/repo" {
+		t.Errorf("FetchThread error = %v, want not found", err)
+	}
+
+	if _, _, err := client.FetchThread("", "repo", "1"); err == nil {
+		t.Error("Expected error for empty owner")
+	}
+	if _, _, err := client.FetchThread("octo", "repo", "abc"); err == nil {
+		t.Error("Expected error for non-numeric number")
+	}
+}
+
+func TestClient_FetchThread_ReviewThreadPages(t *testing.T) {
+	var requests []map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode GraphQL request: %v", err)
+		}
+		requests = append(requests, req.Variables)
+
+		if len(requests) == 1 {
+			w.Write([]byte(`{"data":{"repository":{"issueOrPullRequest":{
+				"__typename": "PullRequest", "number": 4, "title": "Long review",
+				"reviews": {"pageInfo": {"hasNextPage": false}, "nodes": [
+					{"databaseId": 40, "body": "draft", "createdAt": "2024-05-04T09:00:00Z", "submittedAt": null}
+				]},
+				"reviewThreads": {"pageInfo": {"hasNextPage": false}, "nodes": [
+					{"id": "T1", "comments": {"pageInfo": {"hasNextPage": true, "endCursor": "p1"}, "nodes": [
+						{"databaseId": 31, "body": "one", "path": "a.go", "line": 1}
+					]}},
+					{"id": "T2", "comments": {"pageInfo": {"hasNextPage": false}, "nodes": [
+						{"databaseId": 33, "body": "three", "path": "b.go", "line": 2}
+					]}}
+				]}
+			}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"node":{"comments":{"pageInfo": {"hasNextPage": false, "endCursor": "p2"}, "nodes": [
+			{"databaseId": 32, "body": "two", "path": "a.go", "line": 1}
+		]}}}}`))
+	})
+
+	_, comments, err := client.FetchThread("octo", "repo", "4")
+	if err != nil {
+		t.Fatalf("FetchThread failed: %v", err)
+	}
+
+	if len(requests) != 2 || requests[1]["id"] != "T1" || requests[1]["cursor"] != "p1" {
+		t.Fatalf("GraphQL requests = %v, want a second page of thread T1 after p1", requests)
+	}
+	wantIDs := []int{31, 32, 33, 40}
+	if len(comments) != len(wantIDs) {
+		t.Fatalf("Got %d comments, want %d", len(comments), len(wantIDs))
+	}
+	for i, comment := range comments {
+		if comment.ID != wantIDs[i] {
+			t.Errorf("comments[%d] = %d, want %d", i, comment.ID, wantIDs[i])
+		}
+	}
+
+	pending := comments[3]
+	if want := time.Date(2024, 5, 4, 9, 0, 0, 0, time.UTC); !pending.CreatedAt.Equal(want) {
+		t.Errorf("Pending review dated %v, want its creation time %v", pending.CreatedAt, want)
+	}
+}
+
+func TestClient_FetchThread_Cached(t *testing.T) {
+	updatedAt := "2024-05-02T10:00:00Z"
+	bodyHTML := "<p>a</p>"
+	var queries, full, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/graphql":
+			queries++
+			w.Write([]byte(`{"data":{"repository":{"issueOrPullRequest":{
+				"__typename": "PullRequest", "number": 8, "body": "![a](https://example.com/a.png)", "bodyHTML": "` + bodyHTML + `",
+				"url": "https://github.com/octo/repo/pull/8", "updatedAt": "` + updatedAt + `",
+				"comments": {"nodes": [{"databaseId": 1, "body": "first"}]},
+				"reviews": {"nodes": [{"databaseId": 21, "body": "looks good", "submittedAt": "2024-05-02T10:00:00Z"}]},
+				"reviewThreads": {"nodes": [{"comments": {"nodes": [{"databaseId": 30, "body": "inline", "path": "a.go", "originalLine": 3}]}}]}
+			}}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/repo/issues/8":
+			etag := `"` + updatedAt + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			full++
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(`{"number": 8, "updated_at": "` + updatedAt + `", "pull_request": {"url": "https://api.github.com/repos/octo/repo/pulls/8"}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cache := httpcache.New(t.TempDir())
+	fetch := func(run string) {
+		t.Helper()
+		client := NewClientWithOptions(ClientOptions{
+			Timeout:   5 * time.Second,
+			AuthToken: "test-token",
+			BaseURL:   server.URL,
+			Cache:     cache,
+		})
+		issue, comments, err := client.FetchThread("octo", "repo", "8")
+		if err != nil {
+			t.Fatalf("%s: FetchThread failed: %v", run, err)
+		}
+		if !issue.IsPullRequest() || len(comments) != 3 || comments[1].Line != 3 || comments[1].Kind != CommentKindReviewComment || comments[2].Kind != CommentKindReview {
+			t.Errorf("%s: got %+v with comments %+v", run, issue, comments)
+		}
+	}
+	check := func(run string, wantQueries, wantFull, wantNotModified int) {
+		t.Helper()
+		if queries != wantQueries || full != wantFull || notModified != wantNotModified {
+			t.Errorf("%s: %d queries, %d full and %d 304 REST responses, want %d, %d and %d",
+				run, queries, full, notModified, wantQueries, wantFull, wantNotModified)
+		}
+	}
+
+	fetch("first run")
+	check("first run", 1, 0, 0)
+	fetch("second run")
+	check("second run", 1, 1, 0)
+	fetch("third run")
+	check("third run", 1, 1, 1)
+
+	updatedAt = "2024-05-03T10:00:00Z"
+	fetch("after update")
+	check("after update", 2, 2, 1)
+
+	// Signed attachment URLs expire, so such threads are queried every time;
+	// the outdated snapshot only costs a 304
+	bodyHTML = `<img src=\"https://private-user-images.githubusercontent.com/1/a.png?jwt=x\">`
+	updatedAt = "2024-05-04T10:00:00Z"
+	fetch("signed URLs")
+	fetch("signed URLs again")
+	check("signed URLs", 4, 3, 2)
+}
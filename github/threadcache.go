@@ -0,0 +1,87 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// threadSnapshot is the result of FetchThread as kept in the cache
+type threadSnapshot struct {
+	Issue    *Issue            `json:"issue"`
+	Comments []snapshotComment `json:"comments"`
+}
+
+// snapshotComment keeps the comment kind, which Comment leaves out of its
+// JSON form
+type snapshotComment struct {
+	*Comment
+	Kind CommentKind `json:"kind"`
+}
+
+// threadCacheName returns the name a thread snapshot is stored under
+func (c *Client) threadCacheName(owner, repo, num string) string {
+	return fmt.Sprintf("thread %s/%s/%s#%s", c.host, owner, repo, num)
+}
+
+// cachedThread returns the stored snapshot of a thread if the issue's REST
+// endpoint reports the same update time. The REST request is conditional,
+// so an unchanged thread costs no rate limit. Any failure falls back to a
+// fresh query.
+func (c *Client) cachedThread(owner, repo, num string) (*Issue, []*Comment, bool) {
+	if c.opts.Cache == nil {
+		return nil, nil, false
+	}
+	data, ok := c.opts.Cache.Load(c.threadCacheName(owner, repo, num))
+	if !ok {
+		return nil, nil, false
+	}
+	var snapshot threadSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.Issue == nil {
+		return nil, nil, false
+	}
+
+	current, err := c.FetchIssue(owner, repo, num)
+	if err != nil || current.UpdatedAt.IsZero() || !current.UpdatedAt.Equal(snapshot.Issue.UpdatedAt) {
+		return nil, nil, false
+	}
+
+	comments := make([]*Comment, 0, len(snapshot.Comments))
+	for _, stored := range snapshot.Comments {
+		if stored.Comment == nil {
+			return nil, nil, false
+		}
+		stored.Comment.Kind = stored.Kind
+		comments = append(comments, stored.Comment)
+	}
+	return snapshot.Issue, comments, true
+}
+
+// storeThread keeps a thread for cachedThread. Threads whose rendered HTML
+// carries signed attachment URLs are not stored, since those URLs expire
+// within minutes.
+func (c *Client) storeThread(owner, repo, num string, issue *Issue, comments []*Comment) {
+	if c.opts.Cache == nil || issue.UpdatedAt.IsZero() || hasSignedURL(issue.BodyHTML) {
+		return
+	}
+	snapshot := threadSnapshot{Issue: issue, Comments: make([]snapshotComment, 0, len(comments))}
+	for _, comment := range comments {
+		if hasSignedURL(comment.BodyHTML) {
+			return
+		}
+		snapshot.Comments = append(snapshot.Comments, snapshotComment{Comment: comment, Kind: comment.Kind})
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+	// A snapshot that cannot be stored only costs a query on the next run
+	c.opts.Cache.Store(c.threadCacheName(owner, repo, num), data)
+}
+
+// hasSignedURL reports whether rendered HTML contains a signed URL, as
+// GitHub uses for attachments in private repositories
+func hasSignedURL(bodyHTML string) bool {
+	return strings.Contains(bodyHTML, "jwt=")
+}
@@ -2,7 +2,12 @@ module github.com/kojikawamura/gh-ccimg
 
 go 1.24.4
 
-require github.com/cli/go-gh/v2 v2.12.1
+require (
+	github.com/cli/go-gh/v2 v2.12.1
+	github.com/spf13/cobra v1.9.1
+	github.com/yuin/goldmark v1.7.12
+	golang.org/x/image v0.25.0
+)
 
 require (
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
@@ -15,10 +20,8 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/muesli/termenv v0.16.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 	github.com/thlib/go-timezone-local v0.0.0-20210907160436-ef149e42d28e // indirect
-	github.com/yuin/goldmark v1.7.12 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/term v0.30.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
@@ -0,0 +1,369 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FromCacheHeader is set on responses served from the cache after the
+// server confirmed them with 304 Not Modified
+const FromCacheHeader = "X-From-Cache"
+
+// Cache stores GET responses on disk, keyed by URL, along with their ETag
+// and Last-Modified validators. Later requests for the same URL are sent as
+// conditional requests and a 304 Not Modified answer is served from disk.
+type Cache struct {
+	dir string
+}
+
+// entry is the metadata stored next to a cached response body
+type entry struct {
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+// DefaultDir returns the cache directory below the user cache directory
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "gh-ccimg", "http"), nil
+}
+
+// New creates a cache that keeps its files in dir. The directory is created
+// on first write.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir returns the directory the cache keeps its files in
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Transport wraps next so GET requests are revalidated against the cache.
+// Other methods, and range requests, pass straight through.
+func (c *Cache) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{cache: c, next: next}
+}
+
+// Prune removes entries that have not been stored or revalidated within
+// maxAge and returns how many were removed. A maxAge of zero empties the
+// cache.
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, ".json") {
+			// Bodies go with their metadata; leftovers of interrupted
+			// writes are cleared once they are old enough
+			if strings.HasSuffix(name, ".tmp") {
+				if info, err := file.Info(); err == nil && (maxAge == 0 || info.ModTime().Before(cutoff)) {
+					os.Remove(filepath.Join(c.dir, name))
+				}
+			}
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		if maxAge > 0 && !info.ModTime().Before(cutoff) {
+			continue
+		}
+		key := strings.TrimSuffix(name, ".json")
+		if err := c.remove(key); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Load returns the data stored under name with Store and marks it as
+// recently used
+func (c *Cache) Load(name string) ([]byte, bool) {
+	k := nameKey(name)
+	if _, ok := c.load(k); !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.bodyPath(k))
+	if err != nil {
+		return nil, false
+	}
+	c.touch(k)
+	return data, true
+}
+
+// Store saves data under name, for results that do not come from a single
+// GET request. Stored data ages and is pruned like any cached response.
+func (c *Cache) Store(name string, data []byte) error {
+	k := nameKey(name)
+	body, err := c.record(k, &entry{URL: name}, io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+	if _, ok := c.load(k); !ok {
+		return fmt.Errorf("failed to store %s in cache", name)
+	}
+	return nil
+}
+
+// nameKey derives the file name for data stored under name. The prefix
+// keeps names apart from request URLs.
+func nameKey(name string) string {
+	sum := sha256.Sum256([]byte("store\n" + name))
+	return hex.EncodeToString(sum[:])
+}
+
+// key derives the file name for a request. The Accept header is part of
+// the key because the GitHub API returns different bodies per media type.
+func key(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return hex.EncodeToString(sum[:])
+}
+
+// load returns the entry stored under key, if any
+func (c *Cache) load(key string) (*entry, bool) {
+	data, err := os.ReadFile(c.metaPath(key))
+	if err != nil {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	if _, err := os.Stat(c.bodyPath(key)); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+// touch marks an entry as recently used so Prune keeps it
+func (c *Cache) touch(key string) {
+	now := time.Now()
+	os.Chtimes(c.metaPath(key), now, now)
+}
+
+// remove deletes an entry's metadata and body
+func (c *Cache) remove(key string) error {
+	for _, path := range []string{c.metaPath(key), c.bodyPath(key)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) metaPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *Cache) bodyPath(key string) string {
+	return filepath.Join(c.dir, key+".body")
+}
+
+// transport implements http.RoundTripper on top of a Cache
+type transport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	k := key(req)
+	cached, ok := t.cache.load(k)
+	if ok {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		if cachedResp, err := t.cached(k, cached, resp); err == nil {
+			resp.Body.Close()
+			return cachedResp, nil
+		}
+		return resp, nil
+	case resp.StatusCode == http.StatusOK:
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			return resp, nil
+		}
+		e := &entry{
+			URL:          req.URL.String(),
+			Header:       resp.Header.Clone(),
+			ETag:         etag,
+			LastModified: lastModified,
+		}
+		if body, err := t.cache.record(k, e, resp.Body); err == nil {
+			resp.Body = body
+		}
+		return resp, nil
+	default:
+		return resp, nil
+	}
+}
+
+// cached builds the response for a 304 answer from the stored entry. Fresh
+// headers from the 304, such as rate-limit counters, replace stored ones.
+func (t *transport) cached(key string, e *entry, notModified *http.Response) (*http.Response, error) {
+	body, err := os.Open(t.cache.bodyPath(key))
+	if err != nil {
+		return nil, err
+	}
+	info, err := body.Stat()
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	t.cache.touch(key)
+
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for name, values := range notModified.Header {
+		header[name] = values
+	}
+	header.Set(FromCacheHeader, "1")
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          body,
+		ContentLength: info.Size(),
+		Request:       notModified.Request,
+	}, nil
+}
+
+// record returns a body that copies what the caller reads into a temporary
+// file and stores it as the entry once read to the end. Responses the
+// caller abandons part way, for example because they exceed a size limit,
+// are never stored.
+func (c *Cache) record(key string, e *entry, body io.ReadCloser) (io.ReadCloser, error) {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &recordingBody{cache: c, key: key, entry: e, body: body, tmp: tmp}, nil
+}
+
+// recordingBody tees a response body into the cache
+type recordingBody struct {
+	cache *Cache
+	key   string
+	entry *entry
+	body  io.ReadCloser
+	tmp   *os.File
+	done  bool // tmp has been committed or discarded
+}
+
+// Read implements io.Reader
+func (r *recordingBody) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 && !r.done {
+		if _, werr := r.tmp.Write(p[:n]); werr != nil {
+			r.discard()
+		}
+	}
+	if err == io.EOF && !r.done {
+		r.commit()
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (r *recordingBody) Close() error {
+	if !r.done {
+		r.discard()
+	}
+	return r.body.Close()
+}
+
+// commit moves the recorded body into place and writes its metadata
+func (r *recordingBody) commit() {
+	r.done = true
+	if err := r.tmp.Close(); err != nil {
+		os.Remove(r.tmp.Name())
+		return
+	}
+	if err := os.Rename(r.tmp.Name(), r.cache.bodyPath(r.key)); err != nil {
+		os.Remove(r.tmp.Name())
+		return
+	}
+
+	r.entry.StoredAt = time.Now()
+	data, err := json.Marshal(r.entry)
+	if err != nil {
+		return
+	}
+	meta, err := os.CreateTemp(r.cache.dir, r.key+"-*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := meta.Write(data)
+	cerr := meta.Close()
+	if werr != nil || cerr != nil || os.Rename(meta.Name(), r.cache.metaPath(r.key)) != nil {
+		os.Remove(meta.Name())
+	}
+}
+
+// discard drops a partially recorded body
+func (r *recordingBody) discard() {
+	r.done = true
+	r.tmp.Close()
+	os.Remove(r.tmp.Name())
+}
@@ -0,0 +1,172 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport_Revalidates(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("image bytes"))
+	}))
+	defer server.Close()
+
+	cache := New(t.TempDir())
+	client := &http.Client{Transport: cache.Transport(nil)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/a.png")
+		if err != nil {
+			t.Fatalf("Get %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || string(body) != "image bytes" {
+			t.Errorf("Get %d = %d %q, want 200 with cached body", i, resp.StatusCode, body)
+		}
+		if resp.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("Get %d Content-Type = %q, want image/png", i, resp.Header.Get("Content-Type"))
+		}
+		fromCache := resp.Header.Get(FromCacheHeader) != ""
+		if fromCache != (i == 1) {
+			t.Errorf("Get %d from cache = %v", i, fromCache)
+		}
+		if i == 1 && resp.Header.Get("X-RateLimit-Remaining") != "4999" {
+			t.Errorf("Cached response did not pick up fresh headers: %v", resp.Header)
+		}
+	}
+
+	want := []string{"|", `"v1"|Mon, 01 Jan 2024 00:00:00 GMT`}
+	if len(conditional) != 2 || conditional[0] != want[0] || conditional[1] != want[1] {
+		t.Errorf("Conditional headers = %q, want %q", conditional, want)
+	}
+}
+
+func TestTransport_SkipsUncacheable(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("Unexpected conditional request for %s", r.URL.Path)
+		}
+		if r.URL.Path != "/plain" {
+			w.Header().Set("ETag", `"v1"`)
+		}
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: New(dir).Transport(nil)}
+
+	get := func(path string, read int64) {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get %s failed: %v", path, err)
+		}
+		io.ReadAll(io.LimitReader(resp.Body, read))
+		resp.Body.Close()
+	}
+
+	// No validators to revalidate with
+	get("/plain", 2048)
+	get("/plain", 2048)
+	// Abandoned part way, as when a download exceeds the size limit
+	get("/partial", 10)
+	get("/partial", 10)
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("Cache holds %d files, want none", len(files))
+	}
+	if calls != 4 {
+		t.Errorf("Server called %d times, want 4", calls)
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"`+r.URL.Path+`"`)
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache := New(dir)
+	client := &http.Client{Transport: cache.Transport(nil)}
+	for _, path := range []string{"/old", "/new"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get %s failed: %v", path, err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	// Age the entry for /old
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/old", nil)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(dir, key(req)+".json"), old, old)
+
+	removed, err := cache.Prune(24 * time.Hour)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune(24h) = %d, %v, want 1 entry removed", removed, err)
+	}
+	if _, ok := cache.load(key(req)); ok {
+		t.Error("Pruned entry still loads")
+	}
+
+	removed, err = cache.Prune(0)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune(0) = %d, %v, want 1 entry removed", removed, err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Cache holds %d files after emptying", len(files))
+	}
+
+	if removed, err := New(filepath.Join(dir, "missing")).Prune(0); err != nil || removed != 0 {
+		t.Errorf("Prune on missing dir = %d, %v, want 0, nil", removed, err)
+	}
+}
+
+func TestCache_StoreLoad(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(dir)
+
+	if _, ok := cache.Load("thread"); ok {
+		t.Fatal("Load on empty cache succeeded")
+	}
+	if err := cache.Store("thread", []byte("v1")); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if err := cache.Store("thread", []byte("v2")); err != nil {
+		t.Fatalf("Store over existing data failed: %v", err)
+	}
+	if data, ok := cache.Load("thread"); !ok || string(data) != "v2" {
+		t.Errorf("Load = %q, %v, want v2", data, ok)
+	}
+
+	if removed, err := cache.Prune(0); err != nil || removed != 1 {
+		t.Errorf("Prune(0) = %d, %v, want the stored data removed", removed, err)
+	}
+	if _, ok := cache.Load("thread"); ok {
+		t.Error("Pruned data still loads")
+	}
+}
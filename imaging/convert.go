@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Format is an encoding images can be converted to
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+)
+
+// ParseFormat validates a target format name
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "png":
+		return FormatPNG, nil
+	case "jpeg", "jpg":
+		return FormatJPEG, nil
+	default:
+		return "", fmt.Errorf("unsupported target format: %s (expected png or jpeg)", name)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// Convert decodes a PNG, JPEG, GIF, WebP, BMP, TIFF or ICO image and
+// re-encodes it as format, at quality for JPEG. It reports false, leaving
+// data alone, when the image is already in that format. SVG cannot be
+// decoded and returns an error.
+func Convert(data []byte, format Format, quality int) ([]byte, bool, error) {
+	img, current, err := decode(data)
+	if errors.Is(err, image.ErrFormat) {
+		return nil, false, errors.New("cannot decode this image format")
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if Format(current) == format {
+		return data, false, nil
+	}
+
+	var converted []byte
+	switch format {
+	case FormatPNG:
+		converted, err = encodePNG(img)
+	case FormatJPEG:
+		converted, err = encodeJPEG(img, quality)
+	default:
+		err = fmt.Errorf("unsupported target format: %s", format)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return converted, true, nil
+}
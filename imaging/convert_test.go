@@ -0,0 +1,289 @@
+package imaging
+
+import (
+	"bytes"
+	"compress/lzw"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// The fixtures below are 2x2 images with red, green, blue and white
+// pixels in reading order, encoded by hand in each format
+var quad = []color.NRGBA{
+	{R: 0xff, A: 0xff}, {G: 0xff, A: 0xff},
+	{B: 0xff, A: 0xff}, {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+}
+
+// dib builds a 2-pixel wide bitmap: an info header, extra data such as a
+// palette, then the rows padded to four bytes
+func dib(bpp int, height int32, rows [][]byte, extra []byte) []byte {
+	var buf bytes.Buffer
+	header := struct {
+		Size                  uint32
+		Width, Height         int32
+		Planes, BitCount      uint16
+		Compression, ImgSize  uint32
+		XPPM, YPPM            int32
+		ColorsUsed, Important uint32
+	}{Size: 40, Width: 2, Height: height, Planes: 1, BitCount: uint16(bpp)}
+	if bpp <= 8 {
+		header.ColorsUsed = uint32(len(extra) / 4)
+	}
+	binary.Write(&buf, binary.LittleEndian, header)
+	buf.Write(extra)
+	for _, row := range rows {
+		buf.Write(row)
+		buf.Write(make([]byte, (4-len(row)%4)%4))
+	}
+	return buf.Bytes()
+}
+
+func bmpFile(dib []byte, pixelOffset int) []byte {
+	header := make([]byte, 14)
+	copy(header, "BM")
+	binary.LittleEndian.PutUint32(header[2:], uint32(14+len(dib)))
+	binary.LittleEndian.PutUint32(header[10:], uint32(14+pixelOffset))
+	return append(header, dib...)
+}
+
+// tiffFile builds a single-strip 2x2 TIFF with LONG tags in the given byte
+// order, followed by an optional colour map. BitsPerSample is repeated for
+// every sample, as baseline TIFF requires.
+func tiffFile(order binary.ByteOrder, tags map[uint16]uint32, colorMap []uint16, strip []byte) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II*\x00")
+	} else {
+		buf.WriteString("MM\x00*")
+	}
+	binary.Write(&buf, order, uint32(8))
+
+	tags[256], tags[257] = 2, 2 // ImageWidth, ImageLength
+	tags[273] = 0               // StripOffsets, patched below
+	tags[279] = uint32(len(strip))
+	samples := max(1, int(tags[277]))
+	bits := make([]uint16, samples)
+	for i := range bits {
+		bits[i] = uint16(tags[258])
+	}
+	count := len(tags)
+	if colorMap != nil {
+		count++
+	}
+	dataStart := 8 + 2 + count*12 + 4
+	bitsStart := dataStart + len(colorMap)*2
+
+	binary.Write(&buf, order, uint16(count))
+	for _, tag := range []uint16{256, 257, 258, 259, 262, 273, 277, 278, 279, 317, 338} {
+		value, ok := tags[tag]
+		if !ok {
+			continue
+		}
+		binary.Write(&buf, order, tag)
+		switch tag {
+		case 258: // BitsPerSample, inline while two SHORTs fit
+			binary.Write(&buf, order, uint16(3))
+			binary.Write(&buf, order, uint32(samples))
+			if samples <= 2 {
+				binary.Write(&buf, order, append(bits, 0, 0)[:2])
+			} else {
+				binary.Write(&buf, order, uint32(bitsStart))
+			}
+			continue
+		case 273:
+			value = uint32(bitsStart + len(bits)*2)
+		}
+		binary.Write(&buf, order, uint16(4)) // LONG
+		binary.Write(&buf, order, uint32(1))
+		binary.Write(&buf, order, value)
+	}
+	if colorMap != nil {
+		binary.Write(&buf, order, uint16(320)) // ColorMap
+		binary.Write(&buf, order, uint16(3))   // SHORT
+		binary.Write(&buf, order, uint32(len(colorMap)))
+		binary.Write(&buf, order, uint32(dataStart))
+	}
+	binary.Write(&buf, order, uint32(0)) // No further directories
+	binary.Write(&buf, order, colorMap)
+	binary.Write(&buf, order, bits)
+	buf.Write(strip)
+	return buf.Bytes()
+}
+
+func TestDecode_Formats(t *testing.T) {
+	// BMP rows are stored bottom-up in BGR order
+	bmp24 := bmpFile(dib(24, 2, [][]byte{{0xff, 0, 0, 0xff, 0xff, 0xff}, {0, 0, 0xff, 0, 0xff, 0}}, nil), 40)
+
+	palette := []byte{0, 0, 0xff, 0, 0, 0xff, 0, 0, 0xff, 0, 0, 0, 0xff, 0xff, 0xff, 0}
+	bmp8Header := dib(8, -2, nil, palette) // Top-down
+	bmp8 := bmpFile(append(bmp8Header, 0, 1, 0, 0, 2, 3, 0, 0), len(bmp8Header))
+
+	bmp32Header := dib(32, -2, nil, nil)
+	bmp32 := bmpFile(append(bmp32Header,
+		0, 0, 0xff, 0, 0, 0xff, 0, 0,
+		0xff, 0, 0, 0, 0xff, 0xff, 0xff, 0), len(bmp32Header))
+
+	// An icon with a 24-bit bitmap whose mask hides the white pixel
+	iconDIB := dib(24, 4, [][]byte{{0xff, 0, 0, 0xff, 0xff, 0xff}, {0, 0, 0xff, 0, 0xff, 0}, {0x40}, {0}}, nil)
+	ico := icoFile(iconDIB)
+
+	var pngBuf bytes.Buffer
+	png.Encode(&pngBuf, quadImage())
+	icoPNG := icoFile(pngBuf.Bytes())
+
+	rgb := []byte{0xff, 0, 0, 0, 0xff, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}
+	tiffRaw := tiffFile(binary.LittleEndian, map[uint16]uint32{258: 8, 259: 1, 262: 2, 277: 3}, nil, rgb)
+
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	// RGBA with horizontal differencing: second pixel stores deltas
+	zw.Write([]byte{0xff, 0, 0, 0xff, 0x01, 0xff, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0})
+	zw.Close()
+	tiffDeflate := tiffFile(binary.BigEndian, map[uint16]uint32{258: 8, 259: 8, 262: 2, 277: 4, 317: 2, 338: 2}, nil, deflated.Bytes())
+
+	var lzwBuf bytes.Buffer
+	lw := lzw.NewWriter(&lzwBuf, lzw.MSB, 8)
+	lw.Write([]byte{0, 1, 2, 3})
+	lw.Close()
+	colorMap := make([]uint16, 768)
+	for i, c := range quad {
+		colorMap[i], colorMap[256+i], colorMap[512+i] = uint16(c.R)<<8, uint16(c.G)<<8, uint16(c.B)<<8
+	}
+	tiffLZW := tiffFile(binary.LittleEndian, map[uint16]uint32{258: 8, 259: 5, 262: 3, 277: 1}, colorMap, lzwBuf.Bytes())
+
+	tests := []struct {
+		name        string
+		data        []byte
+		format      string
+		transparent int // Index of a pixel expected to be transparent, or -1
+	}{
+		{"bmp 24-bit", bmp24, "bmp", -1},
+		{"bmp 8-bit palette", bmp8, "bmp", -1},
+		{"bmp 32-bit", bmp32, "bmp", -1},
+		{"ico bitmap", ico, "ico", 3},
+		{"ico png", icoPNG, "ico", -1},
+		{"tiff uncompressed", tiffRaw, "tiff", -1},
+		{"tiff deflate predictor", tiffDeflate, "tiff", -1},
+		{"tiff lzw palette", tiffLZW, "tiff", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := image.Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if format != tt.format {
+				t.Errorf("Detected %s, want %s", format, tt.format)
+			}
+			if img.Bounds().Dx() != 2 || img.Bounds().Dy() != 2 {
+				t.Fatalf("Decoded %v, want 2x2", img.Bounds())
+			}
+			config, format, err := image.DecodeConfig(bytes.NewReader(tt.data))
+			if err != nil || format != tt.format || config.Width != 2 || config.Height != 2 {
+				t.Errorf("DecodeConfig = %dx%d %s, %v, want 2x2 %s", config.Width, config.Height, format, err, tt.format)
+			}
+			for i, want := range quad {
+				if i == tt.transparent {
+					want = color.NRGBA{}
+				}
+				got := color.NRGBAModel.Convert(img.At(img.Bounds().Min.X+i%2, img.Bounds().Min.Y+i/2)).(color.NRGBA)
+				if (want.A == 0 && got.A != 0) || (want.A != 0 && got != want) {
+					t.Errorf("Pixel %d = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeConfig_HeaderOnly(t *testing.T) {
+	// Pixel data is missing, which only a full decode notices
+	header := dib(24, 2, nil, nil)
+	truncated := bmpFile(header, len(header))
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(truncated))
+	if err != nil || format != "bmp" || config.Width != 2 || config.Height != 2 {
+		t.Errorf("DecodeConfig = %dx%d %s, %v, want 2x2 bmp", config.Width, config.Height, format, err)
+	}
+	if _, _, err := image.Decode(bytes.NewReader(truncated)); err == nil {
+		t.Error("Expected Decode to fail on missing pixel data")
+	}
+}
+
+func icoFile(payload []byte) []byte {
+	header := []byte{0, 0, 1, 0, 1, 0, 2, 2, 0, 0, 1, 0, 24, 0}
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(payload)))
+	header = binary.LittleEndian.AppendUint32(header, 22)
+	return append(header, payload...)
+}
+
+func quadImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i, c := range quad {
+		img.SetNRGBA(i%2, i/2, c)
+	}
+	return img
+}
+
+func TestConvert(t *testing.T) {
+	bmp := bmpFile(dib(24, 2, [][]byte{{0xff, 0, 0, 0xff, 0xff, 0xff}, {0, 0, 0xff, 0, 0xff, 0}}, nil), 40)
+
+	converted, changed, err := Convert(bmp, FormatPNG, 0)
+	if err != nil || !changed {
+		t.Fatalf("Convert(bmp, png) = %v, %v, want converted", changed, err)
+	}
+	if _, format, err := image.Decode(bytes.NewReader(converted)); err != nil || format != "png" {
+		t.Errorf("Converted data decodes as %q, %v", format, err)
+	}
+
+	jpeg, changed, err := Convert(converted, FormatJPEG, 90)
+	if err != nil || !changed {
+		t.Fatalf("Convert(png, jpeg) = %v, %v, want converted", changed, err)
+	}
+	if _, changed, _ := Convert(jpeg, FormatJPEG, 90); changed {
+		t.Error("Converting a JPEG to JPEG re-encoded it")
+	}
+
+	if _, _, err := Convert([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), FormatPNG, 0); err == nil {
+		t.Error("Expected error for truncated WebP data")
+	}
+	if _, _, err := Convert([]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), FormatPNG, 0); err == nil {
+		t.Error("Expected error for SVG data")
+	}
+}
+
+func TestConvert_WebP(t *testing.T) {
+	// A 1x1 lossless WebP
+	webp, _ := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+	for _, format := range []Format{FormatPNG, FormatJPEG} {
+		converted, changed, err := Convert(webp, format, 90)
+		if err != nil || !changed {
+			t.Fatalf("Convert(webp, %s) = %v, %v, want converted", format, changed, err)
+		}
+		img, got, err := image.Decode(bytes.NewReader(converted))
+		if err != nil || Format(got) != format {
+			t.Errorf("Converted data decodes as %q, %v, want %s", got, err, format)
+			continue
+		}
+		if img.Bounds().Dx() != 1 || img.Bounds().Dy() != 1 {
+			t.Errorf("Converted image is %v, want 1x1", img.Bounds())
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"png": FormatPNG, "JPG": FormatJPEG, "jpeg": FormatJPEG} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("webp"); err == nil {
+		t.Error("Expected error for webp")
+	}
+}
@@ -0,0 +1,162 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FrameMode selects how frames are picked from an animated GIF
+type FrameMode string
+
+const (
+	FramesCount     FrameMode = "count"     // A fixed number of evenly spaced frames
+	FramesAll       FrameMode = "all"       // Every frame
+	FramesKeyframes FrameMode = "keyframes" // Frames that look different from the last one picked
+)
+
+// keyframeDistance is the fingerprint distance at which a frame counts as
+// a new keyframe. Cursor movement and blinking carets stay below it while
+// a dialog opening or a page changing goes well over.
+const keyframeDistance = 4
+
+// FrameSelection describes which frames ExtractFrames returns
+type FrameSelection struct {
+	Mode  FrameMode
+	Count int // Number of frames for FramesCount
+}
+
+// ParseFrameSelection parses a frame count, "all" or "keyframes"
+func ParseFrameSelection(value string) (FrameSelection, error) {
+	switch mode := FrameMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case FramesAll, FramesKeyframes:
+		return FrameSelection{Mode: mode}, nil
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || count < 1 {
+		return FrameSelection{}, fmt.Errorf("invalid frame selection: %s (expected a positive number, all or keyframes)", value)
+	}
+	return FrameSelection{Mode: FramesCount, Count: count}, nil
+}
+
+// Frame is a single frame of an animated GIF, encoded as PNG
+type Frame struct {
+	Data   []byte
+	Index  int           // Position in the animation, starting at 1
+	Delay  time.Duration // How long the frame is shown
+	Offset time.Duration // When the frame is first shown
+	Width  int
+	Height int
+}
+
+// ExtractFrames decodes an animated GIF and returns the selected frames,
+// each composited onto the frames before it as a viewer would show it,
+// along with the total number of frames. GIFs with a single frame and
+// other formats return no frames.
+func ExtractFrames(data []byte, selection FrameSelection) ([]Frame, int, error) {
+	if !bytes.HasPrefix(data, []byte("GIF8")) {
+		return nil, 0, nil
+	}
+	config, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode GIF: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, 0, fmt.Errorf("GIF of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode GIF: %w", err)
+	}
+	total := len(animation.Image)
+	if total < 2 {
+		return nil, total, nil
+	}
+
+	bounds := image.Rect(0, 0, animation.Config.Width, animation.Config.Height)
+	if bounds.Empty() {
+		// Some encoders leave the logical screen size unset
+		for _, frame := range animation.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+	if bounds.Dx()*bounds.Dy() > maxPixels {
+		return nil, total, fmt.Errorf("GIF of %dx%d pixels is too large", bounds.Dx(), bounds.Dy())
+	}
+
+	picked := pickFrames(total, selection)
+	canvas := image.NewRGBA(bounds)
+	var frames []Frame
+	var last *Fingerprint
+	var offset time.Duration
+	for i, frame := range animation.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(animation.Disposal) {
+			disposal = animation.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		delay := time.Duration(animation.Delay[i]) * 10 * time.Millisecond
+		keep := picked[i]
+		if selection.Mode == FramesKeyframes {
+			print := NewFingerprint(canvas)
+			if keep = last == nil || print.Distance(*last) >= keyframeDistance; keep {
+				last = &print
+			}
+		}
+		if keep {
+			encoded, err := encodePNG(canvas)
+			if err != nil {
+				return nil, total, err
+			}
+			frames = append(frames, Frame{
+				Data:   encoded,
+				Index:  i + 1,
+				Delay:  delay,
+				Offset: offset,
+				Width:  bounds.Dx(),
+				Height: bounds.Dy(),
+			})
+		}
+		offset += delay
+
+		switch disposal {
+		case gif.DisposalBackground:
+			// Browsers clear to transparent rather than the background colour
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames, total, nil
+}
+
+// pickFrames marks the frames a selection takes up front. Keyframes are
+// picked while compositing instead.
+func pickFrames(total int, selection FrameSelection) []bool {
+	picked := make([]bool, total)
+	switch {
+	case selection.Mode == FramesAll, selection.Mode == FramesCount && selection.Count >= total:
+		for i := range picked {
+			picked[i] = true
+		}
+	case selection.Mode == FramesCount && selection.Count == 1:
+		picked[0] = true
+	case selection.Mode == FramesCount:
+		// Spread evenly, always including the first and last frame
+		for i := 0; i < selection.Count; i++ {
+			picked[(i*(total-1)+(selection.Count-1)/2)/(selection.Count-1)] = true
+		}
+	}
+	return picked
+}
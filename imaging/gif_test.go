@@ -0,0 +1,151 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"strings"
+	"testing"
+	"time"
+)
+
+// animation encodes one GIF frame per screenshot variant, each shown for
+// delay hundredths of a second
+func animation(t *testing.T, variants []int, delay int) []byte {
+	t.Helper()
+	var anim gif.GIF
+	for _, variant := range variants {
+		frame := image.NewPaletted(image.Rect(0, 0, 64, 48), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), screenshot(64, 48, variant), image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &anim); err != nil {
+		t.Fatalf("EncodeAll failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractFrames(t *testing.T) {
+	data := animation(t, []int{0, 0, 1, 1, 0}, 20)
+
+	tests := []struct {
+		selection FrameSelection
+		want      []int
+	}{
+		{FrameSelection{Mode: FramesAll}, []int{1, 2, 3, 4, 5}},
+		{FrameSelection{Mode: FramesCount, Count: 1}, []int{1}},
+		{FrameSelection{Mode: FramesCount, Count: 2}, []int{1, 5}},
+		{FrameSelection{Mode: FramesCount, Count: 3}, []int{1, 3, 5}},
+		{FrameSelection{Mode: FramesCount, Count: 10}, []int{1, 2, 3, 4, 5}},
+		{FrameSelection{Mode: FramesKeyframes}, []int{1, 3, 5}},
+	}
+
+	for _, tt := range tests {
+		frames, total, err := ExtractFrames(data, tt.selection)
+		if err != nil {
+			t.Fatalf("ExtractFrames(%+v) failed: %v", tt.selection, err)
+		}
+		if total != 5 {
+			t.Errorf("Total = %d, want 5", total)
+		}
+		var got []int
+		for _, frame := range frames {
+			got = append(got, frame.Index)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ExtractFrames(%+v) picked %v, want %v", tt.selection, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ExtractFrames(%+v) picked %v, want %v", tt.selection, got, tt.want)
+				break
+			}
+		}
+	}
+
+	frames, _, _ := ExtractFrames(data, FrameSelection{Mode: FramesCount, Count: 2})
+	last := frames[1]
+	if last.Delay != 200*time.Millisecond || last.Offset != 800*time.Millisecond {
+		t.Errorf("Last frame delay %v at %v, want 200ms at 800ms", last.Delay, last.Offset)
+	}
+	if _, format, err := image.Decode(bytes.NewReader(last.Data)); err != nil || format != "png" {
+		t.Errorf("Frame decodes as %q, %v, want png", format, err)
+	}
+}
+
+func TestExtractFrames_Composites(t *testing.T) {
+	// The second frame only repaints a corner, leaving the rest to show
+	// through from the first
+	background := image.NewPaletted(image.Rect(0, 0, 8, 8), color.Palette{color.White, color.Black})
+	patch := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.White, color.Black})
+	for i := range patch.Pix {
+		patch.Pix[i] = 1
+	}
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{background, patch}, Delay: []int{0, 0}})
+
+	frames, _, err := ExtractFrames(buf.Bytes(), FrameSelection{Mode: FramesAll})
+	if err != nil || len(frames) != 2 {
+		t.Fatalf("ExtractFrames = %d frames, %v, want 2", len(frames), err)
+	}
+	img, _, _ := image.Decode(bytes.NewReader(frames[1].Data))
+	if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 8 {
+		t.Fatalf("Frame is %v, want the full 8x8 canvas", img.Bounds())
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0 {
+		t.Error("Patched corner is not black")
+	}
+	if r, _, _, _ := img.At(7, 7).RGBA(); r != 0xffff {
+		t.Error("Rest of the canvas did not keep the first frame")
+	}
+}
+
+func TestExtractFrames_NotAnimated(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"still gif": encode(t, screenshot(8, 8, 0), "gif"),
+		"png":       encode(t, screenshot(8, 8, 0), "png"),
+	} {
+		if frames, _, err := ExtractFrames(data, FrameSelection{Mode: FramesAll}); err != nil || frames != nil {
+			t.Errorf("%s: got %d frames, %v, want none", name, len(frames), err)
+		}
+	}
+}
+
+func TestExtractFrames_OversizedHeader(t *testing.T) {
+	data := animation(t, []int{0, 1}, 10)
+	// The logical screen size follows the 6-byte GIF signature
+	binary.LittleEndian.PutUint16(data[6:], 65535)
+	binary.LittleEndian.PutUint16(data[8:], 65535)
+
+	if _, _, err := ExtractFrames(data, FrameSelection{}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("ExtractFrames error = %v, want too large", err)
+	}
+}
+
+func TestParseFrameSelection(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    FrameSelection
+		wantErr bool
+	}{
+		{"4", FrameSelection{Mode: FramesCount, Count: 4}, false},
+		{"all", FrameSelection{Mode: FramesAll}, false},
+		{"Keyframes", FrameSelection{Mode: FramesKeyframes}, false},
+		{"0", FrameSelection{}, true},
+		{"first", FrameSelection{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFrameSelection(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFrameSelection(%q) = %+v, %v, want %+v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/bmp"
+)
+
+func init() {
+	image.RegisterFormat("ico", "\x00\x00\x01\x00", decodeICO, decodeICOConfig)
+}
+
+// decodeICO decodes the largest image of a Windows icon, which holds either
+// a PNG or a bitmap without file header. Bitmaps are decoded by
+// golang.org/x/image/bmp, so the same 8, 24 and 32-bit depths are supported.
+func decodeICO(r io.Reader) (image.Image, error) {
+	payload, err := readICO(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(payload, pngSignature) {
+		return png.Decode(bytes.NewReader(payload))
+	}
+
+	file, mask, err := iconBitmap(payload)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := bmp.Decode(bytes.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewNRGBA(decoded.Bounds())
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	applyIconMask(img, mask)
+	return img, nil
+}
+
+// decodeICOConfig reads the dimensions of the image decodeICO would decode
+// from its PNG or bitmap header
+func decodeICOConfig(r io.Reader) (image.Config, error) {
+	payload, err := readICO(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	if bytes.HasPrefix(payload, pngSignature) {
+		return png.DecodeConfig(bytes.NewReader(payload))
+	}
+	file, _, err := iconBitmap(payload)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return bmp.DecodeConfig(bytes.NewReader(file))
+}
+
+// readICO returns the data of the largest image in an icon, preferring the
+// greatest colour depth among images of the same size
+func readICO(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 6 {
+		return nil, errors.New("ico: truncated header")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || 6+count*16 > len(data) {
+		return nil, errors.New("ico: invalid directory")
+	}
+
+	best, bestArea, bestDepth := -1, 0, 0
+	for i := 0; i < count; i++ {
+		entry := data[6+i*16:]
+		// Sizes of 0 stand for 256 pixels
+		width, height := int(entry[0]), int(entry[1])
+		if width == 0 {
+			width = 256
+		}
+		if height == 0 {
+			height = 256
+		}
+		depth := int(binary.LittleEndian.Uint16(entry[6:8]))
+		if area := width * height; area > bestArea || (area == bestArea && depth > bestDepth) {
+			best, bestArea, bestDepth = i, area, depth
+		}
+	}
+
+	entry := data[6+best*16:]
+	size := int(binary.LittleEndian.Uint32(entry[8:12]))
+	offset := int(binary.LittleEndian.Uint32(entry[12:16]))
+	if offset < 0 || size <= 0 || offset+size > len(data) {
+		return nil, errors.New("ico: image data out of range")
+	}
+	return data[offset : offset+size], nil
+}
+
+// iconBitmap turns the bitmap of an icon into a BMP file and returns it
+// along with the icon's 1-bit transparency mask. Icon bitmaps lack the file
+// header and store twice their height: the colour rows, then the mask.
+func iconBitmap(dib []byte) (file, mask []byte, err error) {
+	if len(dib) < 40 {
+		return nil, nil, errors.New("ico: truncated bitmap header")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:4]))
+	if headerSize < 40 || headerSize > len(dib) {
+		return nil, nil, errors.New("ico: invalid bitmap header")
+	}
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:8])))
+	height := int32(binary.LittleEndian.Uint32(dib[8:12])) / 2
+	bpp := int(binary.LittleEndian.Uint16(dib[14:16]))
+	if width <= 0 || height == 0 {
+		return nil, nil, errors.New("ico: invalid bitmap dimensions")
+	}
+
+	pixels := headerSize
+	if bpp <= 8 {
+		colors := int(binary.LittleEndian.Uint32(dib[32:36]))
+		if colors == 0 {
+			colors = 1 << bpp
+		}
+		pixels += colors * 4
+	}
+	if pixels > len(dib) {
+		return nil, nil, errors.New("ico: truncated bitmap palette")
+	}
+
+	header := make([]byte, 14, 14+len(dib))
+	copy(header, "BM")
+	binary.LittleEndian.PutUint32(header[2:], uint32(14+len(dib)))
+	binary.LittleEndian.PutUint32(header[10:], uint32(14+pixels))
+	file = append(header, dib...)
+	binary.LittleEndian.PutUint32(file[14+8:], uint32(height))
+
+	rows := int(height)
+	if rows < 0 {
+		rows = -rows
+	}
+	if colorBytes := (width*bpp + 31) / 32 * 4 * rows; pixels+colorBytes < len(dib) {
+		mask = dib[pixels+colorBytes:]
+	}
+	return file, mask, nil
+}
+
+// applyIconMask clears the pixels an icon's 1-bit AND mask marks as
+// transparent. The mask is stored bottom-up; icons without a complete mask
+// are left opaque.
+func applyIconMask(img *image.NRGBA, mask []byte) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	stride := (width + 31) / 32 * 4
+	if len(mask) < stride*height {
+		return
+	}
+	for y := 0; y < height; y++ {
+		dy := height - 1 - y
+		for x := 0; x < width; x++ {
+			if mask[y*stride+x/8]&(0x80>>(x%8)) != 0 {
+				img.Pix[dy*img.Stride+x*4+3] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// JPEG markers and segment signatures that carry metadata
+const (
+	markerSOS  = 0xda
+	markerEOI  = 0xd9
+	markerAPP1 = 0xe1
+	markerAPP2 = 0xe2
+	markerIPTC = 0xed // APP13, Photoshop resources including IPTC
+	markerCOM  = 0xfe
+
+	exifSignature = "Exif\x00\x00"
+	xmpSignature  = "http://ns.adobe.com/" // Both standard and extended XMP
+	iccSignature  = "ICC_PROFILE\x00"
+)
+
+// EXIF tags inspected while stripping
+const (
+	exifOrientation = 0x0112
+	exifMake        = 0x010f
+	exifModel       = 0x0110
+	exifGPS         = 0x8825
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// StripMetadata removes EXIF, XMP, IPTC, ICC profiles, comments and text
+// chunks from JPEG and PNG images without re-encoding them, and returns a
+// description of everything removed. A JPEG's EXIF orientation is kept, in
+// a minimal EXIF segment of its own, so photos still display upright.
+// Other formats are returned unchanged, as are images that cannot be
+// parsed, along with the error.
+func StripMetadata(data []byte) ([]byte, []string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	default:
+		return data, nil, nil
+	}
+}
+
+func stripJPEG(data []byte) ([]byte, []string, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xff, 0xd8)
+	var removed []string
+
+	pos := 2
+	for pos+2 <= len(data) {
+		if data[pos] != 0xff {
+			return data, nil, errors.New("jpeg: invalid marker")
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xff: // Fill byte
+			pos++
+			continue
+		case marker == markerSOS || marker == markerEOI:
+			// Entropy-coded data follows, with no metadata after it
+			return append(out, data[pos:]...), removed, nil
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7): // Standalone markers
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return data, nil, errors.New("jpeg: truncated segment")
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end < pos+4 || end > len(data) {
+			return data, nil, errors.New("jpeg: invalid segment length")
+		}
+		segment, payload := data[pos:end], data[pos+4:end]
+		pos = end
+
+		var label string
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(payload, []byte(exifSignature)):
+			exif := parseEXIF(payload[len(exifSignature):])
+			label = exif.describe()
+			if exif.orientation > 1 {
+				out = append(out, orientationSegment(exif.orientation)...)
+			}
+		case marker == markerAPP1 && bytes.HasPrefix(payload, []byte(xmpSignature)):
+			label = "XMP"
+		case marker == markerAPP2 && bytes.HasPrefix(payload, []byte(iccSignature)):
+			label = "ICC profile"
+		case marker == markerIPTC:
+			label = "IPTC"
+		case marker == markerCOM:
+			label = "comment"
+		default:
+			out = append(out, segment...)
+			continue
+		}
+		// Large profiles span several segments but are reported once
+		if !slices.Contains(removed, label) {
+			removed = append(removed, label)
+		}
+	}
+	return data, nil, errors.New("jpeg: missing image data")
+}
+
+func stripPNG(data []byte) ([]byte, []string, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	var removed, keywords []string
+
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return data, nil, errors.New("png: invalid chunk length")
+		}
+		kind, payload := string(data[pos+4:pos+8]), data[pos+8:end-4]
+		chunk := data[pos:end]
+		pos = end
+
+		var label string
+		switch kind {
+		case "eXIf":
+			label = parseEXIF(payload).describe()
+		case "iCCP":
+			label = "ICC profile"
+		case "tIME":
+			label = "modification time"
+		case "tEXt", "zTXt", "iTXt":
+			keyword, _, _ := bytes.Cut(payload, []byte{0})
+			if string(keyword) == "XML:com.adobe.xmp" {
+				label = "XMP"
+			} else if !slices.Contains(keywords, string(keyword)) {
+				keywords = append(keywords, string(keyword))
+			}
+		case "IEND":
+			out = append(out, chunk...)
+			if len(keywords) > 0 {
+				removed = append(removed, fmt.Sprintf("text (%s)", strings.Join(keywords, ", ")))
+			}
+			return out, removed, nil
+		default:
+			out = append(out, chunk...)
+			continue
+		}
+		if label != "" {
+			removed = append(removed, label)
+		}
+	}
+	return data, nil, errors.New("png: missing IEND chunk")
+}
+
+// exifInfo is what StripMetadata reports about removed EXIF data
+type exifInfo struct {
+	orientation uint16
+	gps         bool
+	device      string
+}
+
+// parseEXIF reads the first image directory of a TIFF-structured EXIF
+// block. Malformed data yields whatever was read before the problem.
+func parseEXIF(tiff []byte) exifInfo {
+	var info exifInfo
+	if len(tiff) < 8 {
+		return info
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return info
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return info
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	var device []string
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		switch order.Uint16(tiff[entry:]) {
+		case exifOrientation:
+			info.orientation = order.Uint16(tiff[entry+8:])
+		case exifGPS:
+			info.gps = true
+		case exifMake, exifModel:
+			if text := exifString(tiff, entry, order); text != "" {
+				device = append(device, text)
+			}
+		}
+	}
+	info.device = strings.Join(device, " ")
+	return info
+}
+
+// exifString returns the ASCII value of the directory entry at entry
+func exifString(tiff []byte, entry int, order binary.ByteOrder) string {
+	n := int(order.Uint32(tiff[entry+4:]))
+	value := tiff[entry+8 : entry+12]
+	if n > 4 {
+		start := int(order.Uint32(value))
+		if start < 0 || n < 0 || start+n > len(tiff) {
+			return ""
+		}
+		value = tiff[start : start+n]
+	} else {
+		value = value[:max(n, 0)]
+	}
+	return strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+}
+
+func (e exifInfo) describe() string {
+	var details []string
+	if e.gps {
+		details = append(details, "GPS location")
+	}
+	if e.device != "" {
+		details = append(details, "device "+e.device)
+	}
+	if len(details) == 0 {
+		return "EXIF"
+	}
+	return fmt.Sprintf("EXIF (%s)", strings.Join(details, ", "))
+}
+
+// orientationSegment builds an APP1 segment holding an EXIF block with
+// nothing but the orientation tag
+func orientationSegment(orientation uint16) []byte {
+	payload := []byte(exifSignature)
+	payload = append(payload, "MM\x00\x2a"...)
+	payload = binary.BigEndian.AppendUint32(payload, 8) // First directory
+	payload = binary.BigEndian.AppendUint16(payload, 1) // One entry
+	payload = binary.BigEndian.AppendUint16(payload, exifOrientation)
+	payload = binary.BigEndian.AppendUint16(payload, 3) // SHORT
+	payload = binary.BigEndian.AppendUint32(payload, 1)
+	payload = binary.BigEndian.AppendUint16(payload, orientation)
+	payload = append(payload, 0, 0)                     // Value padding
+	payload = binary.BigEndian.AppendUint32(payload, 0) // No further directories
+
+	segment := []byte{0xff, markerAPP1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
@@ -0,0 +1,124 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"strings"
+	"testing"
+)
+
+// exifBlock builds a little-endian EXIF block with orientation, camera
+// make and a GPS pointer
+func exifBlock(orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	entry := func(tag, kind uint16, count, value uint32) {
+		tiff = binary.LittleEndian.AppendUint16(tiff, tag)
+		tiff = binary.LittleEndian.AppendUint16(tiff, kind)
+		tiff = binary.LittleEndian.AppendUint32(tiff, count)
+		tiff = binary.LittleEndian.AppendUint32(tiff, value)
+	}
+	entry(exifOrientation, 3, 1, uint32(orientation))
+	entry(exifMake, 2, 6, 8+2+3*12+4) // Stored after the directory
+	entry(exifGPS, 4, 1, 0)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+	return append(tiff, "Apple\x00"...)
+}
+
+func jpegSegment(marker byte, payload string) []byte {
+	segment := []byte{0xff, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func pngChunk(kind, payload string) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStripMetadata_JPEG(t *testing.T) {
+	plain := encode(t, screenshot(64, 48, 0), "jpeg")
+
+	// Insert metadata segments right after SOI
+	var data []byte
+	data = append(data, plain[:2]...)
+	data = append(data, jpegSegment(markerAPP1, exifSignature+string(exifBlock(6)))...)
+	data = append(data, jpegSegment(markerAPP1, xmpSignature+"xap/1.0/\x00<x:xmpmeta/>")...)
+	data = append(data, jpegSegment(markerAPP2, iccSignature+"\x01\x02profile")...)
+	data = append(data, jpegSegment(markerAPP2, iccSignature+"\x02\x02profile")...)
+	data = append(data, jpegSegment(markerCOM, "taken at home")...)
+	data = append(data, plain[2:]...)
+
+	stripped, removed, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata failed: %v", err)
+	}
+	want := []string{"EXIF (GPS location, device Apple)", "XMP", "ICC profile", "comment"}
+	if strings.Join(removed, "|") != strings.Join(want, "|") {
+		t.Errorf("Removed %q, want %q", removed, want)
+	}
+	for _, leak := range []string{"Apple", "xmpmeta", "profile", "taken at home"} {
+		if bytes.Contains(stripped, []byte(leak)) {
+			t.Errorf("Stripped image still contains %q", leak)
+		}
+	}
+	if _, _, err := image.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("Stripped image does not decode: %v", err)
+	}
+
+	// Only the orientation survives
+	if info := parseEXIF(stripped[2+4+len(exifSignature):]); info.orientation != 6 || info.gps || info.device != "" {
+		t.Errorf("Remaining EXIF = %+v, want orientation 6 only", info)
+	}
+	if len(stripped) != len(plain)+len(orientationSegment(6)) {
+		t.Errorf("Stripped image is %d bytes, want %d", len(stripped), len(plain)+len(orientationSegment(6)))
+	}
+
+	// A clean image comes back byte for byte
+	clean, removed, err := StripMetadata(plain)
+	if err != nil || len(removed) != 0 || !bytes.Equal(clean, plain) {
+		t.Errorf("Clean JPEG changed: removed %q, %v", removed, err)
+	}
+}
+
+func TestStripMetadata_PNG(t *testing.T) {
+	plain := encode(t, screenshot(64, 48, 0), "png")
+	ihdrEnd := len(pngSignature) + 25
+
+	var data []byte
+	data = append(data, plain[:ihdrEnd]...)
+	data = append(data, pngChunk("eXIf", string(exifBlock(1)))...)
+	data = append(data, pngChunk("iCCP", "sRGB\x00\x00profile")...)
+	data = append(data, pngChunk("tEXt", "Software\x00Screenshot Tool")...)
+	data = append(data, pngChunk("iTXt", "XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta/>")...)
+	data = append(data, pngChunk("tEXt", "Author\x00someone")...)
+	data = append(data, plain[ihdrEnd:]...)
+
+	stripped, removed, err := StripMetadata(data)
+	if err != nil {
+		t.Fatalf("StripMetadata failed: %v", err)
+	}
+	want := []string{"EXIF (GPS location, device Apple)", "ICC profile", "XMP", "text (Software, Author)"}
+	if strings.Join(removed, "|") != strings.Join(want, "|") {
+		t.Errorf("Removed %q, want %q", removed, want)
+	}
+	if !bytes.Equal(stripped, plain) {
+		t.Error("Stripped PNG differs from the image without metadata")
+	}
+}
+
+func TestStripMetadata_Passthrough(t *testing.T) {
+	gif := encode(t, screenshot(8, 8, 0), "gif")
+	if out, removed, err := StripMetadata(gif); err != nil || len(removed) != 0 || !bytes.Equal(out, gif) {
+		t.Errorf("GIF changed: removed %q, %v", removed, err)
+	}
+
+	truncated := []byte{0xff, 0xd8, 0xff, markerAPP1, 0x00}
+	if out, _, err := StripMetadata(truncated); err == nil || !bytes.Equal(out, truncated) {
+		t.Errorf("Truncated JPEG = %v, want the input and an error", err)
+	}
+}
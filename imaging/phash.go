@@ -0,0 +1,160 @@
+// Package imaging analyses and transforms downloaded images using the
+// standard library decoders and those of golang.org/x/image
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the GIF decoder
+	_ "image/jpeg" // Register the JPEG decoder
+	_ "image/png"  // Register the PNG decoder
+	"math/bits"
+
+	_ "golang.org/x/image/bmp"  // Register the BMP decoder
+	_ "golang.org/x/image/tiff" // Register the TIFF decoder
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+// maxPixels keeps a corrupt or hostile header from allocating gigabytes
+const maxPixels = 100_000_000
+
+// MaxDistance is the largest possible distance between two fingerprints,
+// reached when every bit differs
+const MaxDistance = 64
+
+// Hash is a 64-bit perceptual hash. Images that look alike have hashes
+// differing in few bits.
+type Hash uint64
+
+// Distance returns the number of bits in which h and other differ
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// Fingerprint combines an average hash and a difference hash. The average
+// hash captures overall brightness, the difference hash the gradients, so
+// requiring both to match keeps screenshots of different views sharing a
+// theme from being taken for each other.
+type Fingerprint struct {
+	Average    Hash
+	Difference Hash
+}
+
+// Distance returns the larger of the average and difference hash distances
+func (f Fingerprint) Distance(other Fingerprint) int {
+	return max(f.Average.Distance(other.Average), f.Difference.Distance(other.Difference))
+}
+
+// Decode decodes a PNG, JPEG, GIF, WebP, BMP, TIFF or ICO image, returning
+// the first frame of an animated GIF
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// decode decodes data and returns its format name, after checking that the
+// dimensions in its header stay within maxPixels
+func decode(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, format, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	return image.Decode(bytes.NewReader(data))
+}
+
+// FingerprintData decodes data and returns its fingerprint
+func FingerprintData(data []byte) (Fingerprint, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	return NewFingerprint(img), nil
+}
+
+// NewFingerprint computes the fingerprint of img
+func NewFingerprint(img image.Image) Fingerprint {
+	return Fingerprint{Average: AverageHash(img), Difference: DifferenceHash(img)}
+}
+
+// AverageHash shrinks img to 8x8 grey pixels and sets a bit for every pixel
+// brighter than their mean
+func AverageHash(img image.Image) Hash {
+	grid := shrink(img, 8, 8)
+
+	var sum uint64
+	for _, v := range grid {
+		sum += uint64(v)
+	}
+	mean := sum / uint64(len(grid))
+
+	var h Hash
+	for i, v := range grid {
+		if uint64(v) > mean {
+			h |= 1 << i
+		}
+	}
+	return h
+}
+
+// flatTolerance is how much brighter than its neighbour a difference hash
+// cell must be to count. Screenshots are mostly flat backgrounds, where
+// compression and dithering noise would otherwise flip bits at random.
+const flatTolerance = 0xffff / 64
+
+// DifferenceHash shrinks img to 9x8 grey pixels and sets a bit for every
+// pixel noticeably brighter than its right-hand neighbour
+func DifferenceHash(img image.Image) Hash {
+	grid := shrink(img, 9, 8)
+
+	var h Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if grid[y*9+x] > grid[y*9+x+1]+flatTolerance {
+				h |= 1 << (y*8 + x)
+			}
+		}
+	}
+	return h
+}
+
+// shrink averages img down to a w x h grid of 16-bit luminance values in
+// row-major order. Every source pixel is counted towards the cell it falls
+// in, so thin details such as text still shift the result. Transparent
+// pixels are treated as white, as they appear on GitHub.
+func shrink(img image.Image, w, h int) []uint32 {
+	bounds := img.Bounds()
+	sums := make([]uint64, w*h)
+	counts := make([]uint64, w*h)
+
+	dx, dy := bounds.Dx(), bounds.Dy()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := (y - bounds.Min.Y) * h / dy
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cell := row*w + (x-bounds.Min.X)*w/dx
+			sums[cell] += uint64(luminance(img.At(x, y).RGBA()))
+			counts[cell]++
+		}
+	}
+
+	grid := make([]uint32, w*h)
+	for i := range grid {
+		if counts[i] > 0 {
+			grid[i] = uint32(sums[i] / counts[i])
+		}
+	}
+	return grid
+}
+
+// luminance converts an alpha-premultiplied colour to grey over a white
+// background using the ITU-R BT.601 weights
+func luminance(r, g, b, a uint32) uint32 {
+	white := 0xffff - a
+	return (299*(r+white) + 587*(g+white) + 114*(b+white)) / 1000
+}
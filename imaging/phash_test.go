@@ -0,0 +1,143 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// screenshot draws a fake UI: a light page with a dark header bar and a
+// couple of blocks whose layout depends on variant
+func screenshot(width, height, variant int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{240, 240, 240, 255}), image.Point{}, draw.Src)
+	fill := func(x0, y0, x1, y1 int, c color.RGBA) {
+		draw.Draw(img, image.Rect(x0*width/100, y0*height/100, x1*width/100, y1*height/100), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	fill(0, 0, 100, 12, color.RGBA{36, 41, 47, 255})
+	if variant == 0 {
+		fill(5, 20, 45, 60, color.RGBA{9, 105, 218, 255})
+		fill(55, 20, 95, 90, color.RGBA{200, 30, 30, 255})
+	} else {
+		fill(5, 60, 95, 90, color.RGBA{9, 105, 218, 255})
+		fill(30, 20, 70, 50, color.RGBA{30, 160, 60, 255})
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 40})
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestFingerprint_NearDuplicates(t *testing.T) {
+	original := screenshot(800, 600, 0)
+	reference, err := FingerprintData(encode(t, original, "png"))
+	if err != nil {
+		t.Fatalf("FingerprintData failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		img     image.Image
+		format  string
+		similar bool
+	}{
+		{"recompressed", original, "jpeg", true},
+		{"gif", original, "gif", true},
+		{"resized", screenshot(1200, 900, 0), "png", true},
+		{"cropped", original.SubImage(image.Rect(6, 4, 796, 592)), "png", true},
+		{"different layout", screenshot(800, 600, 1), "png", false},
+	}
+
+	const threshold = 8
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			print, err := FingerprintData(encode(t, tt.img, tt.format))
+			if err != nil {
+				t.Fatalf("FingerprintData failed: %v", err)
+			}
+			distance := reference.Distance(print)
+			if (distance <= threshold) != tt.similar {
+				t.Errorf("Distance = %d, want similar = %v at threshold %d", distance, tt.similar, threshold)
+			}
+		})
+	}
+}
+
+func TestFingerprint_Transparent(t *testing.T) {
+	// A fully transparent image looks the same as a white one
+	white := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+	clear := image.NewRGBA(image.Rect(0, 0, 16, 16))
+
+	if d := NewFingerprint(white).Distance(NewFingerprint(clear)); d != 0 {
+		t.Errorf("Distance between transparent and white = %d, want 0", d)
+	}
+}
+
+func TestFingerprintData_Unsupported(t *testing.T) {
+	if _, err := FingerprintData([]byte("<svg xmlns='http://www.w3.org/2000/svg'/>")); err == nil {
+		t.Error("Expected error for SVG data")
+	}
+}
+
+// oversizedPNG returns a PNG whose header claims 100000x100000 pixels, with
+// a valid checksum so only the size check can reject it
+func oversizedPNG(t *testing.T) []byte {
+	t.Helper()
+	data := encode(t, screenshot(4, 4, 0), "png")
+	// The IHDR chunk follows the 8-byte signature: length, type, width,
+	// height, five more header bytes and the CRC of type and data
+	binary.BigEndian.PutUint32(data[16:], 100_000)
+	binary.BigEndian.PutUint32(data[20:], 100_000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestDecode_OversizedHeader(t *testing.T) {
+	data := oversizedPNG(t)
+	if _, err := Decode(data); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Decode error = %v, want too large", err)
+	}
+	if _, err := FingerprintData(data); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("FingerprintData error = %v, want too large", err)
+	}
+	if _, _, err := Resize(data, ResizeOptions{MaxDimension: 100}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Resize error = %v, want too large", err)
+	}
+	if _, _, err := Convert(data, FormatJPEG, 90); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Convert error = %v, want too large", err)
+	}
+}
+
+func TestHash_Distance(t *testing.T) {
+	if d := Hash(0).Distance(Hash(^uint64(0))); d != MaxDistance {
+		t.Errorf("Distance = %d, want %d", d, MaxDistance)
+	}
+	if d := Hash(0b1011).Distance(Hash(0b0001)); d != 2 {
+		t.Errorf("Distance = %d, want 2", d)
+	}
+}
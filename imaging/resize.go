@@ -0,0 +1,165 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+)
+
+// Defaults sized to Claude's vision limits: images with a longer edge than
+// 1568 pixels or more than about 1.15 megapixels are scaled down by the
+// model anyway, and anything over 5 MB is rejected
+const (
+	DefaultMaxDimension  = 1568
+	DefaultMaxMegapixels = 1.15
+	DefaultQuality       = 85
+	DefaultMaxBytes      = 5 * 1024 * 1024
+)
+
+// ResizeOptions limits the size of an image. Zero values disable a limit.
+type ResizeOptions struct {
+	MaxDimension int   // Longest edge in pixels
+	MaxPixels    int   // Width times height
+	MaxBytes     int64 // Encoded size; larger PNGs are re-encoded as JPEG
+	Quality      int   // JPEG quality, 1-100
+}
+
+// DefaultResizeOptions returns the limits Claude accepts without scaling
+func DefaultResizeOptions() ResizeOptions {
+	return ResizeOptions{
+		MaxDimension: DefaultMaxDimension,
+		MaxPixels:    int(DefaultMaxMegapixels * 1e6),
+		MaxBytes:     DefaultMaxBytes,
+		Quality:      DefaultQuality,
+	}
+}
+
+// Resized is a downscaled and re-encoded image
+type Resized struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Resize decodes a PNG, JPEG or GIF image and, if it exceeds opts, scales
+// it down and re-encodes it. JPEGs stay JPEG at opts.Quality; PNGs and the
+// first frame of GIFs become PNG so text stays sharp, falling back to JPEG
+// when the PNG would still exceed opts.MaxBytes. It reports false when the
+// image already fits.
+func Resize(data []byte, opts ResizeOptions) (Resized, bool, error) {
+	img, format, err := decode(data)
+	if err != nil {
+		return Resized{}, false, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), opts)
+	tooLarge := opts.MaxBytes > 0 && int64(len(data)) > opts.MaxBytes
+	if width == bounds.Dx() && height == bounds.Dy() && !tooLarge {
+		return Resized{}, false, nil
+	}
+
+	scaled := Downscale(img, width, height)
+	result := Resized{Width: width, Height: height}
+	if format != "jpeg" {
+		result.Data, err = encodePNG(scaled)
+		result.ContentType = "image/png"
+	}
+	if format == "jpeg" || (err == nil && opts.MaxBytes > 0 && int64(len(result.Data)) > opts.MaxBytes) {
+		result.Data, err = encodeJPEG(scaled, opts.Quality)
+		result.ContentType = "image/jpeg"
+	}
+	if err != nil {
+		return Resized{}, false, err
+	}
+	return result, true, nil
+}
+
+// fit returns the largest size with the aspect ratio of width x height
+// that is within opts, never scaling up
+func fit(width, height int, opts ResizeOptions) (int, int) {
+	scale := 1.0
+	if longest := max(width, height); opts.MaxDimension > 0 && longest > opts.MaxDimension {
+		scale = float64(opts.MaxDimension) / float64(longest)
+	}
+	if pixels := width * height; opts.MaxPixels > 0 && pixels > opts.MaxPixels {
+		scale = min(scale, math.Sqrt(float64(opts.MaxPixels)/float64(pixels)))
+	}
+	if scale == 1 {
+		return width, height
+	}
+	return max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+}
+
+// Downscale shrinks img to width x height by averaging the block of source
+// pixels behind every target pixel, which keeps thin lines and text legible
+// where nearest-neighbour sampling would drop them. Sizes larger than img
+// are clamped to its own size.
+func Downscale(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	width, height = min(max(width, 1), sw), min(max(height, 1), sh)
+	if width == sw && height == sh {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			// Alpha is premultiplied, so channels average independently
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeJPEG flattens transparency onto white, as GitHub shows it, since
+// JPEG has no alpha channel
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	if quality <= 0 {
+		quality = DefaultQuality
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,98 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	opts := ResizeOptions{MaxDimension: 400, MaxPixels: 100_000, Quality: 80}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		resized     bool
+		contentType string
+		width       int
+		height      int
+	}{
+		{"small png", encode(t, screenshot(300, 200, 0), "png"), false, "", 0, 0},
+		{"wide png", encode(t, screenshot(1600, 400, 0), "png"), true, "image/png", 400, 100},
+		{"pixel budget", encode(t, screenshot(400, 400, 0), "jpeg"), true, "image/jpeg", 316, 316},
+		{"gif", encode(t, screenshot(800, 600, 0), "gif"), true, "image/png", 365, 273},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, resized, err := Resize(tt.data, opts)
+			if err != nil {
+				t.Fatalf("Resize failed: %v", err)
+			}
+			if resized != tt.resized {
+				t.Fatalf("Resize reported %v, want %v", resized, tt.resized)
+			}
+			if !resized {
+				return
+			}
+
+			img, format, err := image.Decode(bytes.NewReader(result.Data))
+			if err != nil {
+				t.Fatalf("Resized data does not decode: %v", err)
+			}
+			if "image/"+format != tt.contentType || result.ContentType != tt.contentType {
+				t.Errorf("Encoded as %s (%s), want %s", format, result.ContentType, tt.contentType)
+			}
+			if img.Bounds().Dx() != tt.width || img.Bounds().Dy() != tt.height || result.Width != tt.width || result.Height != tt.height {
+				t.Errorf("Resized to %v, want %dx%d", img.Bounds().Size(), tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestResize_MaxBytes(t *testing.T) {
+	// Noise compresses badly as PNG, so the byte limit forces JPEG
+	img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	data := encode(t, img, "png")
+
+	result, resized, err := Resize(data, ResizeOptions{MaxBytes: int64(len(data) / 2), Quality: 70})
+	if err != nil || !resized {
+		t.Fatalf("Resize = %v, %v, want re-encoded", resized, err)
+	}
+	if result.ContentType != "image/jpeg" || result.Width != 300 || len(result.Data) > len(data)/2 {
+		t.Errorf("Got %s %dx%d of %d bytes, want JPEG at full size under %d bytes",
+			result.ContentType, result.Width, result.Height, len(result.Data), len(data)/2)
+	}
+}
+
+func TestResize_Unsupported(t *testing.T) {
+	if _, _, err := Resize([]byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), DefaultResizeOptions()); err == nil {
+		t.Error("Expected error for SVG data")
+	}
+}
+
+func TestDownscale_AveragesBlocks(t *testing.T) {
+	// Alternating black and white columns average to mid grey rather than
+	// collapsing to one of them
+	img := image.NewGray(image.Rect(0, 0, 8, 2))
+	for x := 0; x < 8; x += 2 {
+		img.SetGray(x, 0, color.Gray{Y: 255})
+		img.SetGray(x, 1, color.Gray{Y: 255})
+	}
+
+	got := Downscale(img, 2, 1)
+	if got.Bounds().Dx() != 2 || got.Bounds().Dy() != 1 {
+		t.Fatalf("Downscaled to %v, want 2x1", got.Bounds().Size())
+	}
+	for x := 0; x < 2; x++ {
+		if c := got.RGBAAt(x, 0); c.R < 126 || c.R > 129 || c.A != 255 {
+			t.Errorf("Pixel %d = %v, want mid grey", x, c)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+)
+
+// DefaultSheetColumns is the number of images per row on a contact sheet
+const DefaultSheetColumns = 4
+
+// Contact sheet layout, in pixels
+const (
+	sheetGap    = 8
+	minCellSize = 64
+)
+
+var (
+	sheetBackground = color.RGBA{255, 255, 255, 255}
+	cellBackground  = color.RGBA{234, 238, 242, 255}
+	labelBackground = color.RGBA{36, 41, 47, 255}
+	labelForeground = color.RGBA{255, 255, 255, 255}
+)
+
+// digits is a 3x5 pixel font for the index labels, one row per byte with
+// the leftmost pixel in bit 2
+var digits = [10][5]byte{
+	{7, 5, 5, 5, 7}, {2, 6, 2, 2, 7}, {7, 1, 7, 4, 7}, {7, 1, 7, 1, 7}, {5, 5, 7, 1, 1},
+	{7, 4, 7, 1, 7}, {7, 4, 7, 5, 7}, {7, 1, 1, 1, 1}, {7, 5, 7, 5, 7}, {7, 5, 7, 1, 7},
+}
+
+// SheetOptions lays out contact sheets
+type SheetOptions struct {
+	Columns int // Images per row
+	MaxSize int // Longest edge of a sheet in pixels
+}
+
+// Validate reports whether the options leave room for legible thumbnails
+func (o SheetOptions) Validate() error {
+	if o.Columns < 1 {
+		return fmt.Errorf("contact sheets need at least one column, got %d", o.Columns)
+	}
+	if o.cellWidth() < minCellSize {
+		return fmt.Errorf("a %d pixel sheet is too small for %d columns", o.MaxSize, o.Columns)
+	}
+	return nil
+}
+
+func (o SheetOptions) cellWidth() int {
+	return (o.MaxSize - sheetGap*(o.Columns+1)) / o.Columns
+}
+
+// Tile is an image to place on a contact sheet, labelled with its index
+type Tile struct {
+	Data  []byte
+	Label int
+}
+
+// Sheet is a contact sheet encoded as PNG
+type Sheet struct {
+	Data   []byte
+	Labels []int // Labels of the tiles on the sheet, in reading order
+	Width  int
+	Height int
+}
+
+// ContactSheets composes tiles into grids of opts.Columns thumbnails, each
+// labelled with its index, starting a new sheet whenever one would grow
+// past opts.MaxSize. Thumbnails keep their aspect ratio and are never
+// scaled up. Tiles that cannot be decoded, such as SVG, get an empty cell
+// so the labels still line up with the images.
+func ContactSheets(tiles []Tile, opts SheetOptions) ([]Sheet, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	cellWidth := opts.cellWidth()
+	cellHeight := cellWidth * 3 / 4 // Screenshots are mostly landscape
+	rows := max(1, (opts.MaxSize-sheetGap)/(cellHeight+sheetGap))
+	perSheet := rows * opts.Columns
+
+	var sheets []Sheet
+	for start := 0; start < len(tiles); start += perSheet {
+		batch := tiles[start:min(start+perSheet, len(tiles))]
+		columns := min(opts.Columns, len(batch))
+		used := (len(batch) + columns - 1) / columns
+		canvas := image.NewRGBA(image.Rect(0, 0,
+			sheetGap+columns*(cellWidth+sheetGap), sheetGap+used*(cellHeight+sheetGap)))
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(sheetBackground), image.Point{}, draw.Src)
+
+		sheet := Sheet{Width: canvas.Bounds().Dx(), Height: canvas.Bounds().Dy()}
+		for i, tile := range batch {
+			x := sheetGap + (i%columns)*(cellWidth+sheetGap)
+			y := sheetGap + (i/columns)*(cellHeight+sheetGap)
+			cell := image.Rect(x, y, x+cellWidth, y+cellHeight)
+			draw.Draw(canvas, cell, image.NewUniform(cellBackground), image.Point{}, draw.Src)
+			if img, err := Decode(tile.Data); err == nil {
+				drawThumbnail(canvas, cell, img)
+			}
+			drawLabel(canvas, cell.Min, tile.Label, max(2, cellWidth/80))
+			sheet.Labels = append(sheet.Labels, tile.Label)
+		}
+
+		var err error
+		if sheet.Data, err = encodePNG(canvas); err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// drawThumbnail scales img to fit within cell and draws it centred there,
+// over a white backdrop so transparent images stay readable
+func drawThumbnail(canvas *image.RGBA, cell image.Rectangle, img image.Image) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width == 0 || height == 0 {
+		return
+	}
+	scale := min(1, float64(cell.Dx())/float64(width), float64(cell.Dy())/float64(height))
+	thumb := Downscale(img, max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale)))
+
+	size := thumb.Bounds().Size()
+	at := cell.Min.Add(cell.Size().Sub(size).Div(2))
+	target := image.Rectangle{Min: at, Max: at.Add(size)}
+	draw.Draw(canvas, target, image.NewUniform(sheetBackground), image.Point{}, draw.Src)
+	draw.Draw(canvas, target, thumb, image.Point{}, draw.Over)
+}
+
+// drawLabel writes label in the top-left corner of a cell at origin, as
+// white digits scale pixels wide on a dark box
+func drawLabel(canvas *image.RGBA, origin image.Point, label, scale int) {
+	text := strconv.Itoa(label)
+	box := image.Rect(0, 0, scale+len(text)*4*scale, 7*scale).Add(origin)
+	draw.Draw(canvas, box, image.NewUniform(labelBackground), image.Point{}, draw.Src)
+
+	for i, r := range text {
+		if r < '0' || r > '9' {
+			continue
+		}
+		glyph := digits[r-'0']
+		left := origin.X + scale + i*4*scale
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) == 0 {
+					continue
+				}
+				pixel := image.Rect(0, 0, scale, scale).Add(image.Pt(left+col*scale, origin.Y+(row+1)*scale))
+				draw.Draw(canvas, pixel, image.NewUniform(labelForeground), image.Point{}, draw.Src)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestContactSheets(t *testing.T) {
+	var tiles []Tile
+	for i := 1; i <= 5; i++ {
+		tiles = append(tiles, Tile{Data: encode(t, screenshot(320, 200, i%2), "png"), Label: i})
+	}
+	tiles[2].Data = []byte("<svg/>") // Cannot be decoded
+
+	// Two 188x141 cells per row and two rows per 400 pixel sheet
+	sheets, err := ContactSheets(tiles, SheetOptions{Columns: 2, MaxSize: 400})
+	if err != nil {
+		t.Fatalf("ContactSheets failed: %v", err)
+	}
+	if len(sheets) != 2 {
+		t.Fatalf("Got %d sheets, want 2", len(sheets))
+	}
+	if got := sheets[0].Labels; len(got) != 4 || got[0] != 1 || got[3] != 4 {
+		t.Errorf("First sheet labels = %v, want 1-4", got)
+	}
+	if sheets[0].Width != 400 || sheets[0].Height != 306 {
+		t.Errorf("First sheet is %dx%d, want 400x306", sheets[0].Width, sheets[0].Height)
+	}
+	if sheets[1].Width != 204 || sheets[1].Height != 157 || len(sheets[1].Labels) != 1 {
+		t.Errorf("Second sheet is %dx%d with %v, want 204x157 with one image", sheets[1].Width, sheets[1].Height, sheets[1].Labels)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(sheets[0].Data))
+	if err != nil || format != "png" {
+		t.Fatalf("Sheet decodes as %q, %v, want png", format, err)
+	}
+	probes := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"label box", 8, 8, labelBackground},
+		{"label digit", 8 + 4, 8 + 2, labelForeground}, // Top of the 1
+		{"thumbnail title bar", 8 + 94, 8 + 24, color.RGBA{36, 41, 47, 255}},
+		{"empty cell", 8 + 94, 8 + 149 + 70, cellBackground},
+	}
+	for _, probe := range probes {
+		if got := color.RGBAModel.Convert(img.At(probe.x, probe.y)).(color.RGBA); got != probe.want {
+			t.Errorf("%s at (%d, %d) = %v, want %v", probe.name, probe.x, probe.y, got, probe.want)
+		}
+	}
+}
+
+func TestContactSheets_Invalid(t *testing.T) {
+	tiles := []Tile{{Data: encode(t, screenshot(8, 8, 0), "png"), Label: 1}}
+	for _, opts := range []SheetOptions{{Columns: 0, MaxSize: 1000}, {Columns: 10, MaxSize: 400}} {
+		if _, err := ContactSheets(tiles, opts); err == nil {
+			t.Errorf("ContactSheets(%+v) should fail", opts)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// privateImagesHost serves signed copies of attachments from github.com
+const privateImagesHost = "private-user-images.githubusercontent.com"
+
+// IsGitHubAttachmentURL reports whether rawURL points at a file uploaded to
+// github.com or to one of the given GitHub Enterprise Server hosts. Such
+// files need authentication or a signed URL in private repositories.
+func IsGitHubAttachmentURL(rawURL string, hosts ...string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == privateImagesHost {
+		return true
+	}
+
+	for _, known := range append([]string{"github.com"}, hosts...) {
+		known = strings.ToLower(known)
+		if host == known && attachmentPathRegex.MatchString(u.Path) {
+			return true
+		}
+		// Enterprise Server with subdomain isolation serves uploads from media.HOST
+		if host == "media."+known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// attachmentID returns the UUID identifying an uploaded attachment, or an
+// empty string if url does not contain one
+func attachmentID(url string) string {
+	if idx := strings.IndexAny(url, "?#"); idx > 0 {
+		url = url[:idx]
+	}
+	return strings.ToLower(attachmentUUIDRegex.FindString(url))
+}
+
+// ResolveSignedURLs fills in DownloadURL for attachment references using the
+// signed URLs that GitHub embeds in the rendered body_html of the same
+// content. host is the GitHub host the content came from. References
+// without a signed counterpart are returned unchanged.
+func ResolveSignedURLs(refs []ImageRef, bodyHTML, host string) []ImageRef {
+	if bodyHTML == "" || len(refs) == 0 {
+		return refs
+	}
+
+	signed := make(map[string]string)
+	for _, match := range signedAttachmentRegex.FindAllString(bodyHTML, -1) {
+		url := html.UnescapeString(match)
+		if id := attachmentID(url); id != "" {
+			if _, exists := signed[id]; !exists {
+				signed[id] = url
+			}
+		}
+	}
+
+	for i := range refs {
+		if !IsGitHubAttachmentURL(refs[i].URL, host) {
+			continue
+		}
+		if url, ok := signed[attachmentID(refs[i].URL)]; ok {
+			refs[i].DownloadURL = url
+		}
+	}
+
+	return refs
+}
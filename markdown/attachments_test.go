@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIsGitHubAttachmentURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://github.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666", true},
+		{"https://github.com/owner/repo/assets/12345/0b3f1b7e-1111-2222-3333-444455556666", true},
+		{"https://private-user-images.githubusercontent.com/1/2-0b3f1b7e-1111-2222-3333-444455556666.png?jwt=x", true},
+		{"https://user-images.githubusercontent.com/1/image.png", false},
+		{"https://example.com/user-attachments/assets/x", false},
+		{"https://github.com/owner/repo/issues/1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGitHubAttachmentURL(tt.url); got != tt.want {
+			t.Errorf("IsGitHubAttachmentURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestIsGitHubAttachmentURL_EnterpriseHosts(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://ghe.example.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666", true},
+		{"https://ghe.example.com/org/repo/assets/12/0b3f1b7e-1111-2222-3333-444455556666", true},
+		{"https://ghe.example.com/storage/user/12/files/0b3f1b7e-1111-2222-3333-444455556666", true},
+		{"https://media.ghe.example.com/user/12/files/0b3f1b7e-1111-2222-3333-444455556666", true},
+		{"https://other.example.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666", false},
+		{"http://ghe.example.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGitHubAttachmentURL(tt.url, "ghe.example.com"); got != tt.want {
+			t.Errorf("IsGitHubAttachmentURL(%q, ghe.example.com) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSignedURLs(t *testing.T) {
+	const id = "0b3f1b7e-1111-2222-3333-444455556666"
+	bodyHTML := `<p><a target="_blank" rel="noopener noreferrer" href="https://private-user-images.githubusercontent.com/1/2-` + id +
+		`.png?jwt=eyJ&amp;v=1"><img src="https://private-user-images.githubusercontent.com/1/2-` + id +
+		`.png?jwt=eyJ&amp;v=1" alt="image"></a></p>`
+
+	refs := []ImageRef{
+		{URL: "https://github.com/user-attachments/assets/" + id},
+		{URL: "https://example.com/public.png"},
+		{URL: "https://github.com/user-attachments/assets/99999999-1111-2222-3333-444455556666"},
+	}
+
+	resolved := ResolveSignedURLs(refs, bodyHTML, "github.com")
+
+	want := "https://private-user-images.githubusercontent.com/1/2-" + id + ".png?jwt=eyJ&v=1"
+	if resolved[0].DownloadURL != want {
+		t.Errorf("DownloadURL = %q, want %q", resolved[0].DownloadURL, want)
+	}
+	if resolved[1].DownloadURL != "" {
+		t.Errorf("Non-attachment URL should not be resolved, got %q", resolved[1].DownloadURL)
+	}
+	if resolved[2].DownloadURL != "" {
+		t.Errorf("Attachment without signed URL should not be resolved, got %q", resolved[2].DownloadURL)
+	}
+}
+
+func TestImageRef_DownloadURLNotSerialized(t *testing.T) {
+	data, err := json.Marshal(ImageRef{URL: "https://github.com/a", DownloadURL: "https://signed?jwt=secret"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), "jwt") {
+		t.Errorf("DownloadURL leaked into JSON: %s", data)
+	}
+}
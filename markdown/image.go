@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"fmt"
+	"time"
+)
+
+// SourceKind identifies the kind of content an image was found in
+type SourceKind string
+
+const (
+	// SourceIssueBody is the body of the issue or pull request itself
+	SourceIssueBody SourceKind = "issue_body"
+	// SourceComment is a comment on the issue or pull request
+	SourceComment SourceKind = "comment"
+	// SourceReview is the summary body of a pull request review
+	SourceReview SourceKind = "review"
+	// SourceReviewComment is an inline review comment on a pull request diff
+	SourceReviewComment SourceKind = "review_comment"
+	// SourceDiscussionBody is the body of a discussion
+	SourceDiscussionBody SourceKind = "discussion_body"
+	// SourceDiscussionComment is a top-level comment on a discussion
+	SourceDiscussionComment SourceKind = "discussion_comment"
+	// SourceDiscussionReply is a threaded reply to a discussion comment
+	SourceDiscussionReply SourceKind = "discussion_reply"
+)
+
+// Source describes the piece of content an image reference came from
+type Source struct {
+	Kind      SourceKind `json:"kind"`
+	CommentID int        `json:"comment_id,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Path      string     `json:"path,omitempty"` // File an inline review comment is attached to
+	Line      int        `json:"line,omitempty"` // Line an inline review comment is attached to
+
+	// AuthorAssociation is the author's relationship to the repository,
+	// such as OWNER, MEMBER or NONE
+	AuthorAssociation string `json:"author_association,omitempty"`
+}
+
+// String returns a short human-readable description of the source
+func (s Source) String() string {
+	var desc string
+	switch s.Kind {
+	case SourceComment:
+		desc = fmt.Sprintf("comment %d", s.CommentID)
+	case SourceReview:
+		desc = fmt.Sprintf("review %d", s.CommentID)
+	case SourceReviewComment:
+		desc = fmt.Sprintf("review comment %d", s.CommentID)
+		if s.Path != "" {
+			desc += " on " + s.Path
+			if s.Line > 0 {
+				desc += fmt.Sprintf(":%d", s.Line)
+			}
+		}
+	case SourceIssueBody:
+		desc = "issue body"
+	case SourceDiscussionBody:
+		desc = "discussion body"
+	case SourceDiscussionComment:
+		desc = fmt.Sprintf("discussion comment %d", s.CommentID)
+	case SourceDiscussionReply:
+		desc = fmt.Sprintf("discussion reply %d", s.CommentID)
+	default:
+		desc = "unknown source"
+	}
+
+	if s.Author != "" {
+		desc += " by @" + s.Author
+	}
+	if !s.CreatedAt.IsZero() {
+		desc += " at " + s.CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return desc
+}
+
+// ImageRef is a single image reference found in markdown, together with
+// enough context to tell where in the thread it came from
+type ImageRef struct {
+	URL    string `json:"url"`
+	Alt    string `json:"alt,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Offset int    `json:"offset"` // Byte offset of the URL within the source body
+	Source Source `json:"source"`
+
+	// DownloadURL overrides URL when fetching, e.g. with a short-lived signed
+	// URL for a private attachment. It is never serialized since it embeds a
+	// credential.
+	DownloadURL string `json:"-"`
+}
+
+// URLs returns the bare URLs of the given references, preserving order
+func URLs(refs []ImageRef) []string {
+	urls := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		urls = append(urls, ref.URL)
+	}
+	return urls
+}
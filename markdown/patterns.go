@@ -15,12 +15,33 @@ var (
 	// HTML img tag pattern: <img src="url">
 	htmlImgRegex = regexp.MustCompile(`<img[^>]+src=["']([^"']+)["'][^>]*>`)
 	
+	// Attributes of an HTML img tag, used to recover alt text and titles
+	htmlImgTagRegex    = regexp.MustCompile(`<img[^>]*>`)
+	htmlSrcAttrRegex   = regexp.MustCompile(`\ssrc=["']([^"']+)["']`)
+	htmlAltAttrRegex   = regexp.MustCompile(`\salt=["']([^"']*)["']`)
+	htmlTitleAttrRegex = regexp.MustCompile(`\stitle=["']([^"']*)["']`)
+	
 	// Reference-style markdown images: [alt]: url
 	referenceRegex = regexp.MustCompile(`^\s*\[[^\]]+\]:\s*([^\s]+)`)
 	
 	// GitHub-specific patterns
-	// GitHub attachment URLs: https://github.com/user/repo/assets/...
-	githubAssetRegex = regexp.MustCompile(`https://github\.com/[^/]+/[^/]+/assets/[^\s)]+`)
+	// Candidate attachment URLs on any host, e.g.
+	// https://github.com/user/repo/assets/... or https://media.HOST/...;
+	// only those IsGitHubAttachmentURL accepts for the known hosts are kept
+	githubAssetRegex = regexp.MustCompile(`https://[^/\s)"'<>]+/[^\s)"'<>]+`)
+	
+	// Paths of files uploaded to a GitHub host, which require authentication in
+	// private repositories:
+	// /user-attachments/assets/UUID
+	// /OWNER/REPO/assets/USERID/UUID
+	// /storage/user/USERID/files/UUID (GitHub Enterprise Server)
+	attachmentPathRegex = regexp.MustCompile(`^/(?:user-attachments/assets/|[^/]+/[^/]+/assets/|storage/user/)`)
+	
+	// Attachment identifiers are UUIDs that appear in every form of the URL
+	attachmentUUIDRegex = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	
+	// Signed attachment URLs in rendered body_html
+	signedAttachmentRegex = regexp.MustCompile(`https://[^"'\s<>]+[?&](?:amp;)?jwt=[^"'\s<>]+`)
 	
 	// GitHub user content URLs: https://user-images.githubusercontent.com/...
 	githubUserContentRegex = regexp.MustCompile(`https://[^/]*githubusercontent\.com/[^\s)]+`)
@@ -30,15 +51,15 @@ var (
 	httpImageRegex = regexp.MustCompile(`https?://[^\s)]+(?:\.(?:png|jpg|jpeg|gif|webp|svg|bmp|tiff)|/(?:images?|img|assets|uploads)/[^\s)]+)`)
 )
 
-// extractWithPatterns uses regex patterns to extract image URLs as fallback
-func extractWithPatterns(content string) []string {
+// extractWithPatterns uses regex patterns to extract image URLs as fallback.
+// Attachment URLs are recognised on github.com and the given hosts.
+func extractWithPatterns(content string, hosts ...string) []string {
 	var urls []string
 	
 	// Extract using each pattern
 	patterns := []*regexp.Regexp{
 		markdownImageRegex,
 		htmlImgRegex,
-		githubAssetRegex,
 		githubUserContentRegex,
 		httpImageRegex,
 	}
@@ -48,6 +69,10 @@ func extractWithPatterns(content string) []string {
 		for _, match := range matches {
 			if len(match) > 1 {
 				url := strings.TrimSpace(match[1])
+				// Drop an optional title: ![alt](url "title")
+				if fields := strings.Fields(url); len(fields) > 1 {
+					url = fields[0]
+				}
 				if url != "" && isValidImageURL(url) {
 					urls = append(urls, url)
 				}
@@ -61,6 +86,12 @@ func extractWithPatterns(content string) []string {
 		}
 	}
 	
+	for _, url := range githubAssetRegex.FindAllString(content, -1) {
+		if IsGitHubAttachmentURL(url, hosts...) {
+			urls = append(urls, url)
+		}
+	}
+	
 	// Handle reference-style markdown
 	// First pass: collect reference definitions
 	references := extractReferences(content)
@@ -80,6 +111,25 @@ func extractWithPatterns(content string) []string {
 	return urls
 }
 
+// htmlImageAttributes returns the alt and title attributes of the first
+// HTML img tag in content whose src is the given URL
+func htmlImageAttributes(content, url string) (alt, title string) {
+	for _, tag := range htmlImgTagRegex.FindAllString(content, -1) {
+		src := htmlSrcAttrRegex.FindStringSubmatch(tag)
+		if src == nil || strings.TrimSpace(src[1]) != url {
+			continue
+		}
+		if m := htmlAltAttrRegex.FindStringSubmatch(tag); m != nil {
+			alt = m[1]
+		}
+		if m := htmlTitleAttrRegex.FindStringSubmatch(tag); m != nil {
+			title = m[1]
+		}
+		return alt, title
+	}
+	return "", ""
+}
+
 // extractReferences extracts reference-style markdown definitions
 func extractReferences(content string) map[string]string {
 	references := make(map[string]string)
@@ -1,6 +1,7 @@
 package markdown
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/yuin/goldmark"
@@ -11,18 +12,26 @@ import (
 // ExtractImageURLs extracts all image URLs from markdown content
 // Uses goldmark AST parser for accurate parsing
 func ExtractImageURLs(content string) []string {
+	return URLs(ExtractImageRefs(content, Source{}))
+}
+
+// ExtractImageRefs extracts all image references from markdown content,
+// tagging each with the given source. References are returned in the order
+// they appear in the content. Bare attachment URLs are recognised on
+// github.com and on any GitHub Enterprise Server hosts given.
+func ExtractImageRefs(content string, source Source, hosts ...string) []ImageRef {
 	if content == "" {
-		return []string{}
+		return []ImageRef{}
 	}
 
-	var urls []string
+	var refs []ImageRef
 	
 	// Create goldmark parser
 	md := goldmark.New()
 	
 	// Parse markdown to AST
-	source := []byte(content)
-	reader := text.NewReader(source)
+	src := []byte(content)
+	reader := text.NewReader(src)
 	doc := md.Parser().Parse(reader)
 	
 	// Walk the AST to find image nodes
@@ -35,7 +44,11 @@ func ExtractImageURLs(content string) []string {
 		if img, ok := node.(*ast.Image); ok {
 			url := string(img.Destination)
 			if url != "" && isValidImageURL(url) {
-				urls = append(urls, url)
+				refs = append(refs, ImageRef{
+					URL:   url,
+					Alt:   altText(img, src),
+					Title: string(img.Title),
+				})
 			}
 		}
 		
@@ -43,11 +56,72 @@ func ExtractImageURLs(content string) []string {
 	})
 	
 	// Also try fallback regex patterns for malformed markdown
-	fallbackURLs := extractWithPatterns(content)
-	urls = append(urls, fallbackURLs...)
+	for _, url := range extractWithPatterns(content, hosts...) {
+		refs = append(refs, ImageRef{URL: url})
+	}
 	
-	// Deduplicate URLs
-	return deduplicateURLs(urls)
+	// Deduplicate, keeping the first (AST) reference since it carries alt text
+	refs = deduplicateRefs(refs)
+
+	for i := range refs {
+		refs[i].Source = source
+		refs[i].Offset = urlOffset(content, refs[i].URL)
+		if refs[i].Alt == "" && refs[i].Title == "" {
+			refs[i].Alt, refs[i].Title = htmlImageAttributes(content, refs[i].URL)
+		}
+	}
+
+	// Restore document order, since the fallback patterns run after the
+	// AST walk and would otherwise trail behind
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Offset < refs[j].Offset
+	})
+
+	return refs
+}
+
+// urlOffset returns the byte offset at which url appears in content on
+// its own, searching forward past occurrences that are only the start of a
+// longer URL, e.g. image.png within image.png?v=123. URLs that cannot be
+// found sort last.
+func urlOffset(content, url string) int {
+	first := -1
+	for start := 0; start <= len(content); {
+		idx := strings.Index(content[start:], url)
+		if idx < 0 {
+			break
+		}
+		idx += start
+		if first < 0 {
+			first = idx
+		}
+		if end := idx + len(url); end == len(content) || strings.IndexByte(urlTerminators, content[end]) >= 0 {
+			return idx
+		}
+		start = idx + 1
+	}
+	if first < 0 {
+		return len(content)
+	}
+	return first
+}
+
+// urlTerminators are the characters that can follow a URL in markdown or
+// HTML without being part of it
+const urlTerminators = " \t\r\n)\"'<>]"
+
+// altText collects the plain text content of an image node
+func altText(img *ast.Image, source []byte) string {
+	var sb strings.Builder
+	ast.Walk(img, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			if t, ok := node.(*ast.Text); ok {
+				sb.Write(t.Value(source))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
 }
 
 // isValidImageURL checks if a URL looks like an image URL
@@ -115,4 +189,25 @@ func deduplicateURLs(urls []string) []string {
 	}
 	
 	return result
-}
\ No newline at end of file
+}
+
+// deduplicateRefs removes references to duplicate URLs while preserving order.
+// The first reference to each URL wins.
+func deduplicateRefs(refs []ImageRef) []ImageRef {
+	first := make(map[string]ImageRef)
+	for _, ref := range refs {
+		normalized := strings.TrimSpace(ref.URL)
+		if _, exists := first[normalized]; !exists {
+			ref.URL = normalized
+			first[normalized] = ref
+		}
+	}
+
+	urls := deduplicateURLs(URLs(refs))
+	result := make([]ImageRef, 0, len(urls))
+	for _, url := range urls {
+		result = append(result, first[url])
+	}
+
+	return result
+}
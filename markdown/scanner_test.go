@@ -2,8 +2,11 @@ package markdown
 
 import (
 	"reflect"
+	"slices"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestExtractImageURLs(t *testing.T) {
@@ -129,6 +132,133 @@ func TestExtractImageURLs(t *testing.T) {
 	}
 }
 
+func TestExtractImageURLs_DocumentOrder(t *testing.T) {
+	// The HTML tag is only picked up by the fallback patterns, which run
+	// after the AST walk; it must still come back in document order.
+	content := `<img src="https://example.com/first.png">
+![second](https://example.com/second.png)
+<img src="https://example.com/third.png">
+![fourth](https://example.com/fourth.png)`
+
+	expected := []string{
+		"https://example.com/first.png",
+		"https://example.com/second.png",
+		"https://example.com/third.png",
+		"https://example.com/fourth.png",
+	}
+
+	for i := 0; i < 5; i++ {
+		result := ExtractImageURLs(content)
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("ExtractImageURLs() = %v, want %v", result, expected)
+		}
+	}
+}
+
+func TestExtractImageRefs_PrefixURLOffsets(t *testing.T) {
+	// The second URL is a prefix of the first, so a plain substring search
+	// would place it inside the first image
+	content := `![versioned](https://example.com/image.png?v=123)
+Some text
+![plain](https://example.com/image.png)`
+
+	refs := ExtractImageRefs(content, Source{})
+	if len(refs) != 2 {
+		t.Fatalf("ExtractImageRefs() returned %d refs, want 2: %+v", len(refs), refs)
+	}
+	want := strings.LastIndex(content, "https://example.com/image.png")
+	if refs[1].URL != "https://example.com/image.png" || refs[1].Offset != want {
+		t.Errorf("Second ref = %s at %d, want image.png at %d", refs[1].URL, refs[1].Offset, want)
+	}
+	if refs[0].Offset != strings.Index(content, "https://example.com/image.png?v=123") {
+		t.Errorf("First ref offset = %d", refs[0].Offset)
+	}
+}
+
+func TestExtractImageRefs_EnterpriseAttachments(t *testing.T) {
+	// Bare attachment links without image syntax or a file extension
+	content := `Recording: https://ghe.example.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666
+Isolated: https://media.ghe.example.com/user/12/files/0b3f1b7e-1111-2222-3333-444455556666
+Unrelated: https://media.other.example.com/user/12/files/0b3f1b7e-1111-2222-3333-444455556666`
+
+	want := []string{
+		"https://ghe.example.com/user-attachments/assets/0b3f1b7e-1111-2222-3333-444455556666",
+		"https://media.ghe.example.com/user/12/files/0b3f1b7e-1111-2222-3333-444455556666",
+	}
+	if got := URLs(ExtractImageRefs(content, Source{}, "ghe.example.com")); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractImageRefs() = %v, want %v", got, want)
+	}
+	// Without the host only the generic /assets/ pattern applies
+	if got := URLs(ExtractImageRefs(content, Source{})); slices.Contains(got, want[1]) {
+		t.Errorf("Without the host configured got %v, want no media URL", got)
+	}
+}
+
+func TestExtractImageRefs(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	source := Source{
+		Kind:      SourceComment,
+		CommentID: 42,
+		Author:    "octocat",
+		CreatedAt: created,
+	}
+	content := `Expected:
+![expected layout](https://example.com/expected.png "Before the fix")
+<img src="https://example.com/actual.png" alt="actual layout" title="After">`
+
+	refs := ExtractImageRefs(content, source)
+	if len(refs) != 2 {
+		t.Fatalf("ExtractImageRefs() returned %d refs, want 2: %+v", len(refs), refs)
+	}
+
+	want := []ImageRef{
+		{
+			URL:    "https://example.com/expected.png",
+			Alt:    "expected layout",
+			Title:  "Before the fix",
+			Offset: strings.Index(content, "https://example.com/expected.png"),
+			Source: source,
+		},
+		{
+			URL:    "https://example.com/actual.png",
+			Alt:    "actual layout",
+			Title:  "After",
+			Offset: strings.Index(content, "https://example.com/actual.png"),
+			Source: source,
+		},
+	}
+
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("ExtractImageRefs() = %+v, want %+v", refs, want)
+	}
+}
+
+func TestSourceString(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		source Source
+		want   string
+	}{
+		{"issue body", Source{Kind: SourceIssueBody, Author: "alice", CreatedAt: created}, "issue body by @alice at 2024-03-01T09:30:00Z"},
+		{"comment", Source{Kind: SourceComment, CommentID: 7, Author: "bob"}, "comment 7 by @bob"},
+		{"review", Source{Kind: SourceReview, CommentID: 8}, "review 8"},
+		{"review comment", Source{Kind: SourceReviewComment, CommentID: 9, Path: "ui/app.tsx", Line: 12}, "review comment 9 on ui/app.tsx:12"},
+		{"discussion body", Source{Kind: SourceDiscussionBody, Author: "carol"}, "discussion body by @carol"},
+		{"discussion comment", Source{Kind: SourceDiscussionComment, CommentID: 10}, "discussion comment 10"},
+		{"discussion reply", Source{Kind: SourceDiscussionReply, CommentID: 11, Author: "dan"}, "discussion reply 11 by @dan"},
+		{"unknown", Source{}, "unknown source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.source.String(); got != tt.want {
+				t.Errorf("Source.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsValidImageURL(t *testing.T) {
 	tests := []struct {
 		name     string
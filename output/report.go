@@ -0,0 +1,347 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kojikawamura/gh-ccimg/download"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/storage"
+)
+
+// Format selects how results are written to stdout
+type Format string
+
+const (
+	// FormatText is the default human-readable output
+	FormatText Format = "text"
+	// FormatJSON writes a single JSON document per run
+	FormatJSON Format = "json"
+	// FormatNDJSON writes one JSON record per line
+	FormatNDJSON Format = "ndjson"
+)
+
+// Record types used in the "type" field of every record
+const (
+	RecordImage   = "image"
+	RecordFailure = "failure"
+	RecordSheet   = "sheet"
+)
+
+// CategoryStorage is the error category for images that downloaded but
+// could not be stored
+const CategoryStorage download.ErrorCategory = "storage"
+
+// ParseFormat validates and normalizes an output format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s (expected text, json or ndjson)", name)
+	}
+}
+
+// Image is the machine-readable record for a stored image
+type Image struct {
+	Type        string          `json:"type"`
+	Target      string          `json:"target,omitempty"` // Set on NDJSON records only
+	Index       int             `json:"index"`            // 1-based, matches img-NN and "Image N"
+	URL         string          `json:"url"`
+	ContentType string          `json:"content_type"`
+	Size        int64           `json:"size"`
+	SHA256      string          `json:"sha256"`
+	Path        string          `json:"path,omitempty"`
+	DataURI     string          `json:"data_uri,omitempty"`
+	Alt         string          `json:"alt,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Source      markdown.Source `json:"source"`
+
+	// Duplicates lists the other places the same image appeared when it
+	// was stored only once
+	Duplicates []Occurrence `json:"duplicates,omitempty"`
+	// Similar lists near-duplicates skipped in favour of this image
+	Similar []SimilarOccurrence `json:"similar,omitempty"`
+	// Resized is the downscaled copy saved next to the original with
+	// --resize in disk mode
+	Resized *Resized `json:"resized,omitempty"`
+	// Frame is set on images extracted from an animated GIF with
+	// --gif-frames, whose URL carries a #frame=N fragment
+	Frame *Frame `json:"frame,omitempty"`
+	// MetadataRemoved describes what --strip-metadata removed, such as
+	// "EXIF (GPS location)" or "ICC profile"
+	MetadataRemoved []string `json:"metadata_removed,omitempty"`
+	// Warnings are problems that did not stop the image being stored,
+	// such as a format --convert-to could not decode
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Resized describes a downscaled copy of an image
+type Resized struct {
+	Path        string `json:"path"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// Frame locates an extracted frame within its animation
+type Frame struct {
+	URL      string `json:"url"`   // The animated GIF
+	Index    int    `json:"index"` // 1-based position in the animation
+	Count    int    `json:"count"`
+	DelayMS  int64  `json:"delay_ms"`
+	OffsetMS int64  `json:"offset_ms"` // When the frame is first shown
+}
+
+// Occurrence is another place an image with identical content appeared
+type Occurrence struct {
+	URL    string          `json:"url"`
+	Alt    string          `json:"alt,omitempty"`
+	Source markdown.Source `json:"source"`
+}
+
+// SimilarOccurrence is a near-duplicate of an image along with its
+// perceptual hash distance in bits
+type SimilarOccurrence struct {
+	Occurrence
+	Distance int `json:"distance"`
+}
+
+// Failure is the machine-readable record for an image that could not be
+// downloaded or stored
+type Failure struct {
+	Type     string                 `json:"type"`
+	Target   string                 `json:"target,omitempty"` // Set on NDJSON records only
+	Position int                    `json:"position"`         // 1-based position among all extracted URLs
+	URL      string                 `json:"url"`
+	Category download.ErrorCategory `json:"category"`
+	Error    string                 `json:"error"`
+	Source   markdown.Source        `json:"source"`
+}
+
+// Sheet is the machine-readable record for a contact sheet
+type Sheet struct {
+	Type        string `json:"type"`
+	Target      string `json:"target,omitempty"` // Set on NDJSON records only
+	Index       int    `json:"index"`            // 1-based, matches contact-sheet-NN
+	Path        string `json:"path,omitempty"`
+	DataURI     string `json:"data_uri,omitempty"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Images      []int  `json:"images"` // Indexes of the images on the sheet, as labelled
+}
+
+// Report collects the images and failures for a single target
+type Report struct {
+	Target   string    `json:"target"`
+	Error    string    `json:"error,omitempty"` // Set when the target could not be processed
+	Images   []Image   `json:"images"`
+	Failures []Failure `json:"failures"`
+	Sheets   []Sheet   `json:"sheets,omitempty"` // Contact sheets made with --contact-sheet
+
+	warnings map[string][]string // Pending per-image warnings by URL, see Warn
+	frames   map[string]Frame    // Pending frame details by URL, see SetFrame
+}
+
+// NewReport creates an empty report for the given target
+func NewReport(target string) *Report {
+	return &Report{
+		Target:   target,
+		Images:   make([]Image, 0),
+		Failures: make([]Failure, 0),
+	}
+}
+
+// Warn records a warning for the image downloaded from url, attached to
+// its record once the image is added with AddEntry
+func (r *Report) Warn(url, message string) {
+	if r.warnings == nil {
+		r.warnings = make(map[string][]string)
+	}
+	r.warnings[url] = append(r.warnings[url], message)
+}
+
+// SetFrame records that the image with url is a frame of an animated GIF,
+// attached to its record once the image is added with AddEntry
+func (r *Report) SetFrame(url string, frame Frame) {
+	if r.frames == nil {
+		r.frames = make(map[string]Frame)
+	}
+	r.frames[url] = frame
+}
+
+// AddEntry records a stored image. In memory mode the entry location is
+// base64 data and is emitted as a data URI; otherwise it is a file path.
+func (r *Report) AddEntry(entry storage.Entry, inMemory bool) {
+	image := Image{
+		Type:        RecordImage,
+		Index:       len(r.Images) + 1,
+		URL:         entry.Ref.URL,
+		ContentType: entry.ContentType,
+		Size:        entry.Size,
+		SHA256:      entry.SHA256,
+		Alt:         entry.Ref.Alt,
+		Title:       entry.Ref.Title,
+		Source:      entry.Ref.Source,
+	}
+	for _, ref := range entry.Duplicates {
+		image.Duplicates = append(image.Duplicates, Occurrence{URL: ref.URL, Alt: ref.Alt, Source: ref.Source})
+	}
+	for _, similar := range entry.Similar {
+		ref := similar.Ref
+		image.Similar = append(image.Similar, SimilarOccurrence{
+			Occurrence: Occurrence{URL: ref.URL, Alt: ref.Alt, Source: ref.Source},
+			Distance:   similar.Distance,
+		})
+	}
+	image.Warnings = r.warnings[entry.Ref.URL]
+	image.MetadataRemoved = entry.Stripped
+	if frame, ok := r.frames[entry.Ref.URL]; ok {
+		image.Frame = &frame
+	}
+	if variant := entry.Resized; variant != nil {
+		image.Resized = &Resized{
+			Path:        variant.Location,
+			ContentType: variant.ContentType,
+			Size:        variant.Size,
+			Width:       variant.Width,
+			Height:      variant.Height,
+		}
+	}
+	if inMemory {
+		image.DataURI = DataURI(entry.ContentType, entry.Location)
+	} else {
+		image.Path = entry.Location
+	}
+	r.Images = append(r.Images, image)
+}
+
+// AddSheet records a contact sheet showing the images with the given
+// indexes
+func (r *Report) AddSheet(variant storage.Variant, images []int, inMemory bool) {
+	sheet := Sheet{
+		Type:        RecordSheet,
+		Index:       len(r.Sheets) + 1,
+		ContentType: variant.ContentType,
+		Size:        variant.Size,
+		Width:       variant.Width,
+		Height:      variant.Height,
+		Images:      images,
+	}
+	if inMemory {
+		sheet.DataURI = DataURI(variant.ContentType, variant.Location)
+	} else {
+		sheet.Path = variant.Location
+	}
+	r.Sheets = append(r.Sheets, sheet)
+}
+
+// AddFailure records a download failure
+func (r *Report) AddFailure(result download.Result) {
+	r.addFailure(result.Index, result.Ref, download.CategorizeError(result.Error), result.Error)
+}
+
+// AddStorageFailure records an image that downloaded but could not be stored
+func (r *Report) AddStorageFailure(result download.Result, err error) {
+	r.addFailure(result.Index, result.Ref, CategoryStorage, err)
+}
+
+// addFailure appends a failure record
+func (r *Report) addFailure(index int, ref markdown.ImageRef, category download.ErrorCategory, err error) {
+	failure := Failure{
+		Type:     RecordFailure,
+		Position: index + 1,
+		URL:      ref.URL,
+		Category: category,
+		Source:   ref.Source,
+	}
+	if err != nil {
+		failure.Error = err.Error()
+	}
+	r.Failures = append(r.Failures, failure)
+}
+
+// Write emits the report in the given structured format
+func Write(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatNDJSON:
+		return writeRecords(w, report)
+	default:
+		return fmt.Errorf("format %s is not a structured output format", format)
+	}
+}
+
+// WriteBatch emits the reports for several targets. JSON output is a single
+// array with one document per target; NDJSON records are tagged with their
+// target so they can be told apart.
+func WriteBatch(w io.Writer, format Format, reports []*Report) error {
+	switch format {
+	case FormatJSON:
+		if reports == nil {
+			reports = make([]*Report, 0)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	case FormatNDJSON:
+		for _, report := range reports {
+			if err := writeRecords(w, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("format %s is not a structured output format", format)
+	}
+}
+
+// writeRecords writes one NDJSON line per image, failure and contact sheet
+// in the report
+func writeRecords(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	for _, image := range report.Images {
+		image.Target = report.Target
+		if err := encoder.Encode(image); err != nil {
+			return err
+		}
+	}
+	for _, failure := range report.Failures {
+		failure.Target = report.Target
+		if err := encoder.Encode(failure); err != nil {
+			return err
+		}
+	}
+	for _, sheet := range report.Sheets {
+		sheet.Target = report.Target
+		if err := encoder.Encode(sheet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DataURI builds a data URI from a content type and base64 payload
+func DataURI(contentType, encoded string) string {
+	mediaType := contentType
+	if idx := strings.Index(mediaType, ";"); idx > 0 {
+		mediaType = mediaType[:idx]
+	}
+	mediaType = strings.TrimSpace(mediaType)
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, encoded)
+}
@@ -0,0 +1,302 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/download"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/storage"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"json", FormatJSON, false},
+		{"NDJSON", FormatNDJSON, false},
+		{" json ", FormatJSON, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataURI(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"image/png", "data:image/png;base64,AAAA"},
+		{"image/jpeg; charset=binary", "data:image/jpeg;base64,AAAA"},
+		{"", "data:application/octet-stream;base64,AAAA"},
+	}
+
+	for _, tt := range tests {
+		if got := DataURI(tt.contentType, "AAAA"); got != tt.want {
+			t.Errorf("DataURI(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func newTestReport() *Report {
+	source := markdown.Source{Kind: markdown.SourceComment, CommentID: 5, Author: "designer"}
+	report := NewReport("owner/repo#1")
+	report.AddEntry(storage.Entry{
+		Location:    "out/img-01.png",
+		ContentType: "image/png",
+		Size:        4,
+		SHA256:      "abc123",
+		Ref:         markdown.ImageRef{URL: "https://example.com/a.png", Alt: "after", Source: source},
+	}, false)
+	report.AddFailure(download.Result{
+		Index: 1,
+		URL:   "https://example.com/b.png",
+		Ref:   markdown.ImageRef{URL: "https://example.com/b.png", Source: source},
+		Error: &download.FetchError{Category: download.CategoryHTTPStatus, StatusCode: 404, Err: errors.New("HTTP 404")},
+	})
+	return report
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, newTestReport()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if decoded.Target != "owner/repo#1" {
+		t.Errorf("Target = %q, want %q", decoded.Target, "owner/repo#1")
+	}
+	if len(decoded.Images) != 1 || decoded.Images[0].Path != "out/img-01.png" {
+		t.Errorf("Images = %+v, want one image at out/img-01.png", decoded.Images)
+	}
+	if decoded.Images[0].Source.Author != "designer" {
+		t.Errorf("Image source author = %q, want %q", decoded.Images[0].Source.Author, "designer")
+	}
+	if len(decoded.Failures) != 1 {
+		t.Fatalf("Failures = %+v, want one failure", decoded.Failures)
+	}
+	if decoded.Failures[0].Category != download.CategoryHTTPStatus {
+		t.Errorf("Failure category = %q, want %q", decoded.Failures[0].Category, download.CategoryHTTPStatus)
+	}
+	if decoded.Failures[0].Position != 2 {
+		t.Errorf("Failure position = %d, want 2", decoded.Failures[0].Position)
+	}
+}
+
+func TestWrite_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatNDJSON, newTestReport()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Line is not valid JSON: %v\n%s", err, scanner.Text())
+		}
+		types = append(types, record["type"].(string))
+	}
+
+	if strings.Join(types, ",") != "image,failure" {
+		t.Errorf("Record types = %v, want [image failure]", types)
+	}
+}
+
+func TestWriteBatch(t *testing.T) {
+	second := NewReport("owner/repo#2")
+
+	var buf bytes.Buffer
+	if err := WriteBatch(&buf, FormatJSON, []*Report{newTestReport(), second}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	var decoded []Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 2 || decoded[1].Target != "owner/repo#2" {
+		t.Errorf("Decoded reports = %+v, want two reports", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteBatch(&buf, FormatNDJSON, []*Report{newTestReport(), second}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Line is not valid JSON: %v\n%s", err, scanner.Text())
+		}
+		if record["target"] != "owner/repo#1" {
+			t.Errorf("Record target = %v, want owner/repo#1", record["target"])
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Got %d NDJSON records, want 2", lines)
+	}
+}
+
+func TestWrite_MemoryEntry(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	report.AddEntry(storage.Entry{Location: "AAAA", ContentType: "image/gif"}, true)
+
+	if report.Images[0].DataURI != "data:image/gif;base64,AAAA" {
+		t.Errorf("DataURI = %q", report.Images[0].DataURI)
+	}
+	if report.Images[0].Path != "" {
+		t.Errorf("Path should be empty in memory mode, got %q", report.Images[0].Path)
+	}
+}
+
+func TestReport_AddEntryDuplicates(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	report.AddEntry(storage.Entry{
+		Location: "out/img-01.png",
+		SHA256:   "abc123",
+		Ref:      markdown.ImageRef{URL: "https://example.com/a.png"},
+		Duplicates: []markdown.ImageRef{
+			{URL: "https://camo.githubusercontent.com/x", Alt: "again", Source: markdown.Source{Kind: markdown.SourceComment, CommentID: 9}},
+		},
+		Similar: []storage.Similar{
+			{Ref: markdown.ImageRef{URL: "https://example.com/retake.png"}, Distance: 0},
+		},
+	}, false)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatNDJSON, report); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	var image Image
+	if err := json.Unmarshal(buf.Bytes(), &image); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(image.Duplicates) != 1 || image.Duplicates[0].Source.CommentID != 9 || image.Duplicates[0].Alt != "again" {
+		t.Errorf("Duplicates = %+v, want comment 9", image.Duplicates)
+	}
+	if len(image.Similar) != 1 || image.Similar[0].URL != "https://example.com/retake.png" {
+		t.Errorf("Similar = %+v, want the retake", image.Similar)
+	}
+	if !strings.Contains(buf.String(), `"distance":0`) {
+		t.Errorf("Distance of an identical-looking image omitted from %s", buf.String())
+	}
+}
+
+func TestReport_AddEntryResized(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	report.AddEntry(storage.Entry{Location: "out/img-01.png"}, false)
+	report.AddEntry(storage.Entry{
+		Location: "out/img-02.png",
+		Resized:  &storage.Variant{Location: "out/resized/img-02.jpg", ContentType: "image/jpeg", Size: 900, Width: 1568, Height: 882},
+	}, false)
+
+	if report.Images[0].Resized != nil {
+		t.Errorf("Image that was not resized has %+v", report.Images[0].Resized)
+	}
+	want := Resized{Path: "out/resized/img-02.jpg", ContentType: "image/jpeg", Size: 900, Width: 1568, Height: 882}
+	if got := report.Images[1].Resized; got == nil || *got != want {
+		t.Errorf("Resized = %+v, want %+v", got, want)
+	}
+}
+
+func TestReport_Warn(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	report.Warn("https://example.com/a.webp", "not converted to png: cannot decode this image format")
+	report.AddEntry(storage.Entry{Location: "out/img-01.webp", Ref: markdown.ImageRef{URL: "https://example.com/a.webp"}}, false)
+	report.AddEntry(storage.Entry{Location: "out/img-02.png", Ref: markdown.ImageRef{URL: "https://example.com/b.bmp"}}, false)
+
+	if got := report.Images[0].Warnings; len(got) != 1 || !strings.Contains(got[0], "not converted") {
+		t.Errorf("Warnings = %q, want the conversion warning", got)
+	}
+	if got := report.Images[1].Warnings; len(got) != 0 {
+		t.Errorf("Converted image has warnings %q", got)
+	}
+}
+
+func TestReport_SetFrame(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	frame := Frame{URL: "https://example.com/demo.gif", Index: 3, Count: 12, DelayMS: 100, OffsetMS: 200}
+	report.SetFrame("https://example.com/demo.gif#frame=3", frame)
+	report.AddEntry(storage.Entry{Location: "out/img-01.png", Ref: markdown.ImageRef{URL: "https://example.com/demo.gif#frame=3"}}, false)
+	report.AddEntry(storage.Entry{Location: "out/img-02.png", Ref: markdown.ImageRef{URL: "https://example.com/still.png"}}, false)
+
+	if got := report.Images[0].Frame; got == nil || *got != frame {
+		t.Errorf("Frame = %+v, want %+v", got, frame)
+	}
+	if got := report.Images[1].Frame; got != nil {
+		t.Errorf("Still image has frame %+v", got)
+	}
+}
+
+func TestReport_AddSheet(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	report.AddSheet(storage.Variant{Location: "out/contact-sheet-01.png", ContentType: "image/png", Size: 900, Width: 1568, Height: 1200}, []int{1, 2, 3}, false)
+	report.AddSheet(storage.Variant{Location: "aGVsbG8=", ContentType: "image/png", Size: 5}, []int{4}, true)
+
+	if got := report.Sheets[0]; got.Index != 1 || got.Path != "out/contact-sheet-01.png" || len(got.Images) != 3 {
+		t.Errorf("First sheet = %+v", got)
+	}
+	if got := report.Sheets[1]; got.Index != 2 || got.DataURI != "data:image/png;base64,aGVsbG8=" || got.Path != "" {
+		t.Errorf("In-memory sheet = %+v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatNDJSON, report); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var record Sheet
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil || record.Type != RecordSheet || record.Target != "owner/repo#1" {
+		t.Errorf("NDJSON sheet record = %+v, %v", record, err)
+	}
+}
+
+func TestReport_AddEntryMetadataRemoved(t *testing.T) {
+	report := NewReport("owner/repo#1")
+	report.AddEntry(storage.Entry{Location: "out/img-01.jpg", Stripped: []string{"EXIF (GPS location)", "ICC profile"}}, false)
+	report.AddEntry(storage.Entry{Location: "out/img-02.png"}, false)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, report); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := report.Images[0].MetadataRemoved; len(got) != 2 || got[0] != "EXIF (GPS location)" {
+		t.Errorf("MetadataRemoved = %q, want the stripped metadata", got)
+	}
+	if strings.Count(buf.String(), "metadata_removed") != 1 {
+		t.Errorf("Image without metadata should omit the field: %s", buf.String())
+	}
+}
+
+func TestWrite_TextFormatRejected(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, FormatText, NewReport("x")); err == nil {
+		t.Error("Write with text format should return an error")
+	}
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxWait is the longest pause a Governor accepts by default. Limits
+// that reset later than this are reported as errors rather than waited out.
+const DefaultMaxWait = 2 * time.Minute
+
+// Observer is notified whenever requests to host are paused for wait
+type Observer func(host string, wait time.Duration)
+
+// Governor coordinates rate-limit pauses across goroutines. When one request
+// to a host is rate limited, every later request to that host waits until
+// the limit resets instead of each worker discovering the limit on its own.
+type Governor struct {
+	mu       sync.Mutex
+	until    map[string]time.Time // host -> time requests may resume
+	maxWait  time.Duration
+	observer Observer
+	now      func() time.Time
+}
+
+// New creates a governor that accepts pauses of at most maxWait
+func New(maxWait time.Duration) *Governor {
+	return &Governor{
+		until:   make(map[string]time.Time),
+		maxWait: maxWait,
+		now:     time.Now,
+	}
+}
+
+// SetObserver sets the function notified when a pause begins or is extended
+func (g *Governor) SetObserver(observer Observer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.observer = observer
+}
+
+// Pause holds back requests to host for wait. It reports false without
+// pausing when wait exceeds the governor's maximum, so the caller can fail
+// instead. Pauses only ever extend; a shorter wait than the one already in
+// force is a no-op.
+func (g *Governor) Pause(host string, wait time.Duration) bool {
+	if wait > g.maxWait {
+		return false
+	}
+	if wait <= 0 {
+		return true
+	}
+
+	g.mu.Lock()
+	resume := g.now().Add(wait)
+	if !resume.After(g.until[host]) {
+		g.mu.Unlock()
+		return true
+	}
+	g.until[host] = resume
+	observer := g.observer
+	g.mu.Unlock()
+
+	if observer != nil {
+		observer(host, wait)
+	}
+	return true
+}
+
+// Wait blocks until requests to host may resume or ctx is done
+func (g *Governor) Wait(ctx context.Context, host string) error {
+	for {
+		g.mu.Lock()
+		wait := g.until[host].Sub(g.now())
+		g.mu.Unlock()
+		if wait <= 0 {
+			return ctx.Err()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			// Loop in case the pause was extended while sleeping
+		}
+	}
+}
+
+// Delay returns how long the response headers ask the client to wait before
+// the next request: Retry-After when present, otherwise the time until
+// X-RateLimit-Reset once X-RateLimit-Remaining has reached zero. ok is false
+// when the headers carry no rate-limit information.
+func Delay(header http.Header, now time.Time) (wait time.Duration, ok bool) {
+	if wait, ok := ParseRetryAfter(header.Get("Retry-After"), now); ok {
+		return wait, true
+	}
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return 0, false
+	}
+	wait = time.Unix(reset, 0).Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// ParseRetryAfter parses a Retry-After value, given either in seconds or as
+// an HTTP date
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGovernor_PauseAndWait(t *testing.T) {
+	g := New(time.Minute)
+
+	var reported []time.Duration
+	g.SetObserver(func(host string, wait time.Duration) {
+		if host != "example.com" {
+			t.Errorf("Observer host = %q, want example.com", host)
+		}
+		reported = append(reported, wait)
+	})
+
+	if !g.Pause("example.com", 50*time.Millisecond) {
+		t.Fatal("Pause rejected a wait below the maximum")
+	}
+	// A shorter pause while one is in force neither shortens nor re-reports it
+	g.Pause("example.com", time.Millisecond)
+	if len(reported) != 1 {
+		t.Errorf("Observer called %d times, want 1", len(reported))
+	}
+
+	start := time.Now()
+	if err := g.Wait(context.Background(), "example.com"); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Wait returned after %v, want about 50ms", elapsed)
+	}
+
+	// Other hosts are not held back
+	start = time.Now()
+	g.Pause("example.com", 50*time.Millisecond)
+	if err := g.Wait(context.Background(), "other.example.com"); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("Wait for an unpaused host took %v", elapsed)
+	}
+}
+
+func TestGovernor_PauseTooLong(t *testing.T) {
+	g := New(time.Second)
+	if g.Pause("example.com", time.Hour) {
+		t.Error("Pause accepted a wait above the maximum")
+	}
+	if err := g.Wait(context.Background(), "example.com"); err != nil {
+		t.Errorf("Wait failed after rejected pause: %v", err)
+	}
+}
+
+func TestGovernor_WaitCanceled(t *testing.T) {
+	g := New(time.Minute)
+	g.Pause("example.com", time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.Wait(ctx, "example.com"); err != context.DeadlineExceeded {
+		t.Errorf("Wait error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantWait time.Duration
+		wantOK   bool
+	}{
+		{"no headers", nil, 0, false},
+		{"retry after seconds", map[string]string{"Retry-After": "30"}, 30 * time.Second, true},
+		{"retry after date", map[string]string{"Retry-After": now.Add(time.Minute).UTC().Format(http.TimeFormat)}, time.Minute, true},
+		{"exhausted", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)}, 90 * time.Second, true},
+		{"reset passed", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(-time.Second).Unix(), 10)}, 0, true},
+		{"budget left", map[string]string{"X-RateLimit-Remaining": "12", "X-RateLimit-Reset": strconv.FormatInt(now.Unix(), 10)}, 0, false},
+		{"garbage", map[string]string{"Retry-After": "soon"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			wait, ok := Delay(header, now)
+			if wait != tt.wantWait || ok != tt.wantOK {
+				t.Errorf("Delay() = %v, %v, want %v, %v", wait, ok, tt.wantWait, tt.wantOK)
+			}
+		})
+	}
+}
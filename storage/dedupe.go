@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+// DedupeMode selects what happens to an image whose content is identical to
+// one already stored, such as a screenshot re-uploaded in several comments
+// or served through both camo and its original URL
+type DedupeMode string
+
+const (
+	// DedupeOff stores every occurrence as its own image
+	DedupeOff DedupeMode = "off"
+	// DedupeSkip stores the content once and records the other places it
+	// appeared on the first entry
+	DedupeSkip DedupeMode = "skip"
+	// DedupeLink gives every occurrence its own file in disk mode, but as a
+	// hard link to the first one rather than a copy. Memory storage treats
+	// it like DedupeSkip.
+	DedupeLink DedupeMode = "link"
+)
+
+// ParseDedupeMode validates a dedupe mode name
+func ParseDedupeMode(name string) (DedupeMode, error) {
+	switch mode := DedupeMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case DedupeOff, DedupeSkip, DedupeLink:
+		return mode, nil
+	case "":
+		return DedupeSkip, nil
+	default:
+		return "", fmt.Errorf("unsupported dedupe mode: %s (expected skip, link or off)", name)
+	}
+}
+
+// findHash returns the index of the first entry with the given content
+// hash, or -1
+func findHash(entries []Entry, hash string) int {
+	for i, entry := range entries {
+		if entry.SHA256 == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// addDuplicate records ref as another place the content of entries[i]
+// appeared
+func addDuplicate(entries []Entry, i int, ref markdown.ImageRef) {
+	entries[i].Duplicates = append(entries[i].Duplicates, ref)
+}
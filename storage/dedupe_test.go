@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+func TestParseDedupeMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    DedupeMode
+		wantErr bool
+	}{
+		{"", DedupeSkip, false},
+		{"skip", DedupeSkip, false},
+		{"LINK", DedupeLink, false},
+		{"off", DedupeOff, false},
+		{"copy", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDedupeMode(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDedupeMode(%q) = %q, %v, want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// dedupeRefs are three references where the first and last carry the same
+// screenshot, e.g. the original upload and its camo proxy
+var dedupeRefs = []markdown.ImageRef{
+	{URL: "https://example.com/shot.png", Source: markdown.Source{Kind: markdown.SourceIssueBody}},
+	{URL: "https://example.com/other.png", Source: markdown.Source{Kind: markdown.SourceComment, CommentID: 1}},
+	{URL: "https://camo.githubusercontent.com/abc", Source: markdown.Source{Kind: markdown.SourceComment, CommentID: 2}},
+}
+
+var dedupeData = [][]byte{[]byte("same"), []byte("different"), []byte("same")}
+
+func TestDiskStorage_Dedupe(t *testing.T) {
+	tests := []struct {
+		mode      DedupeMode
+		wantFiles int
+	}{
+		{DedupeOff, 3},
+		{DedupeSkip, 2},
+		{DedupeLink, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			ds, _ := NewDiskStorage(t.TempDir(), false)
+			ds.SetDedupe(tt.mode)
+
+			var paths []string
+			for i, ref := range dedupeRefs {
+				path, err := ds.StoreRef(dedupeData[i], "image/png", ref)
+				if err != nil {
+					t.Fatalf("StoreRef(%s) failed: %v", ref.URL, err)
+				}
+				paths = append(paths, path)
+			}
+
+			if ds.Count() != tt.wantFiles || len(ds.Entries()) != tt.wantFiles {
+				t.Fatalf("Stored %d files and %d entries, want %d", ds.Count(), len(ds.Entries()), tt.wantFiles)
+			}
+
+			first := ds.Entries()[0]
+			switch tt.mode {
+			case DedupeSkip:
+				if paths[2] != paths[0] {
+					t.Errorf("Duplicate stored at %s, want the first copy %s", paths[2], paths[0])
+				}
+				if len(first.Duplicates) != 1 || first.Duplicates[0].Source.CommentID != 2 {
+					t.Errorf("Duplicates = %+v, want comment 2", first.Duplicates)
+				}
+			case DedupeLink:
+				a, _ := os.Stat(paths[0])
+				b, _ := os.Stat(paths[2])
+				if paths[2] == paths[0] || !os.SameFile(a, b) {
+					t.Errorf("%s is not a hard link to %s", paths[2], paths[0])
+				}
+				if len(first.Duplicates) != 0 {
+					t.Errorf("Linked duplicates recorded on first entry: %+v", first.Duplicates)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStorage_Dedupe(t *testing.T) {
+	ms := NewMemoryStorage()
+	ms.SetDedupe(DedupeSkip)
+	for i, ref := range dedupeRefs {
+		if _, err := ms.StoreRef(dedupeData[i], "image/png", ref); err != nil {
+			t.Fatalf("StoreRef failed: %v", err)
+		}
+	}
+
+	entries := ms.Entries()
+	if ms.Count() != 2 || len(entries) != 2 {
+		t.Fatalf("Stored %d images, want 2", ms.Count())
+	}
+	if len(entries[0].Duplicates) != 1 || entries[0].Duplicates[0].URL != dedupeRefs[2].URL {
+		t.Errorf("Duplicates = %+v, want the camo URL", entries[0].Duplicates)
+	}
+}
@@ -4,14 +4,36 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/security"
 )
 
+// ResizedDir is the subdirectory of the output directory that downscaled
+// copies are saved to
+const ResizedDir = "resized"
+
+// sheetName is the filename pattern of contact sheets in the output
+// directory, numbered from 1
+const sheetName = "contact-sheet-%02d.png"
+
 // DiskStorage handles file-based storage of images
 type DiskStorage struct {
 	outputDir string
 	force     bool
 	files     []string
+	entries   []Entry
+	manifest  *Manifest // Set in sync mode, see SetManifest
+	next      int       // Next filename index to try in sync mode
+	names     *NameTemplate
+	dedupe    DedupeMode
+	similar   int             // Near-duplicate threshold, see SetSimilarity
+	strip     bool            // Remove metadata before writing
+	used      map[string]bool // Filenames written by this storage
 }
 
 // NewDiskStorage creates a new disk storage instance
@@ -51,39 +73,361 @@ func NewDiskStorage(outputDir string, force bool) (*DiskStorage, error) {
 		outputDir: cleanDir,
 		force:     force,
 		files:     make([]string, 0),
+		entries:   make([]Entry, 0),
+		used:      make(map[string]bool),
+		dedupe:    DedupeOff,
 	}, nil
 }
 
+// SetNameTemplate names files with a template instead of img-NN
+func (ds *DiskStorage) SetNameTemplate(names *NameTemplate) {
+	ds.names = names
+}
+
+// SetDedupe sets how images identical to one already stored are handled
+func (ds *DiskStorage) SetDedupe(mode DedupeMode) {
+	ds.dedupe = mode
+}
+
+// SetSimilarity skips images whose perceptual fingerprint is within
+// threshold bits of one stored in this run, recording them on that entry
+// instead. Images kept by an earlier sync run are not compared since they
+// are not read back. 0 disables near-duplicate detection.
+func (ds *DiskStorage) SetSimilarity(threshold int) {
+	ds.similar = threshold
+}
+
+// SetStripMetadata removes EXIF, XMP and similar metadata from JPEG and
+// PNG images before they are written. Files kept by an earlier sync run
+// are left as they are.
+func (ds *DiskStorage) SetStripMetadata(strip bool) {
+	ds.strip = strip
+}
+
+// SetManifest switches the storage to sync mode. Images whose URL the
+// manifest already lists are kept rather than written again, new images
+// take the next free filename instead of overwriting existing files, and
+// every stored image is recorded in the manifest.
+func (ds *DiskStorage) SetManifest(manifest *Manifest) {
+	ds.manifest = manifest
+}
+
+// Keep records an image saved by an earlier sync run without touching its
+// file. It reports false when the manifest has no file for the URL.
+func (ds *DiskStorage) Keep(ref markdown.ImageRef) (string, bool) {
+	if ds.manifest == nil {
+		return "", false
+	}
+	existing, ok := ds.manifest.Lookup(ref.URL)
+	if !ok {
+		return "", false
+	}
+
+	path := ds.manifest.Path(existing)
+	if ds.dedupe == DedupeSkip {
+		if i := findHash(ds.entries, existing.SHA256); i >= 0 {
+			addDuplicate(ds.entries, i, ref)
+			return ds.entries[i].Location, true
+		}
+	}
+	ds.files = append(ds.files, path)
+	ds.entries = append(ds.entries, Entry{
+		Location:    path,
+		ContentType: existing.ContentType,
+		Size:        existing.Size,
+		SHA256:      existing.SHA256,
+		Ref:         ref,
+	})
+	return path, true
+}
+
+// KeepFrames records, like Keep, the frames an earlier sync run extracted
+// from the animated GIF at ref.URL. It reports false when the manifest has
+// no complete set of frames for the URL.
+func (ds *DiskStorage) KeepFrames(ref markdown.ImageRef) ([]string, bool) {
+	if ds.manifest == nil {
+		return nil, false
+	}
+	frames, ok := ds.manifest.LookupFrames(ref.URL)
+	if !ok {
+		return nil, false
+	}
+
+	paths := make([]string, 0, len(frames))
+	for _, url := range frames {
+		frame := ref
+		frame.URL = url
+		if path, ok := ds.Keep(frame); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths, true
+}
+
 // Store saves image data to disk and returns the file path
 func (ds *DiskStorage) Store(data []byte, contentType, url string) (string, error) {
+	return ds.StoreRef(data, contentType, markdown.ImageRef{URL: url})
+}
+
+// StoreRef saves image data to disk, recording where the image was
+// referenced, and returns the file path. Name templates see the number of
+// images stored before it as its position.
+func (ds *DiskStorage) StoreRef(data []byte, contentType string, ref markdown.ImageRef) (string, error) {
+	return ds.StoreRefAt(len(ds.files), data, contentType, ref)
+}
+
+// StoreRefAt is StoreRef for the image at the given 0-based position among
+// the images extracted from a thread, which name templates see as .Index
+func (ds *DiskStorage) StoreRefAt(position int, data []byte, contentType string, ref markdown.ImageRef) (string, error) {
 	if len(data) == 0 {
 		return "", fmt.Errorf("cannot store empty data")
 	}
 	
+	// In sync mode an image saved by an earlier run is kept as is
+	if path, ok := ds.Keep(ref); ok {
+		return path, nil
+	}
+	
+	// Metadata goes first so copies differing only in EXIF are identical
+	var stripped []string
+	if ds.strip {
+		data, stripped = stripMetadata(data)
+	}
+	
+	// Identical content already stored is recorded as a duplicate or
+	// linked to, depending on the dedupe mode
+	hash := HashData(data)
+	original := -1
+	if ds.dedupe != DedupeOff {
+		original = findHash(ds.entries, hash)
+	}
+	if original >= 0 && ds.dedupe == DedupeSkip {
+		addDuplicate(ds.entries, original, ref)
+		if ds.manifest != nil {
+			ds.manifest.Record(ref.URL, ds.manifestEntry(ds.entries[original]))
+		}
+		return ds.entries[original].Location, nil
+	}
+	var print *imaging.Fingerprint
+	if original < 0 && ds.similar > 0 {
+		print = fingerprint(data)
+		if i, distance := findSimilar(ds.entries, print, ds.similar); i >= 0 {
+			addSimilar(ds.entries, i, ref, distance)
+			if ds.manifest != nil {
+				ds.manifest.Record(ref.URL, ds.manifestEntry(ds.entries[i]))
+			}
+			return ds.entries[i].Location, nil
+		}
+	}
+	
 	// Determine file extension
-	extension := DetermineExtension(contentType, url)
+	extension := DetermineExtension(contentType, ref.URL)
 	
 	// Generate filename
-	index := len(ds.files)
-	filename := GenerateFilename(index, extension)
-	filepath := filepath.Join(ds.outputDir, filename)
+	filename, err := ds.filename(position, data, extension, ref)
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(ds.outputDir, filename)
 	
 	// Check if file already exists and handle overwrite protection
-	if !ds.force {
-		if _, err := os.Stat(filepath); err == nil {
-			return "", fmt.Errorf("file %s already exists (use --force to overwrite)", filepath)
+	if !ds.force && ds.manifest == nil {
+		if _, err := os.Stat(filePath); err == nil {
+			return "", fmt.Errorf("file %s already exists (use --force to overwrite)", filePath)
 		}
 	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", filePath, err)
+	}
+	ds.used[filename] = true
 	
-	// Write file with proper permissions
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
-		return "", fmt.Errorf("failed to write file %s: %w", filepath, err)
+	// Write file with proper permissions, or hard-link a duplicate to the
+	// first copy, falling back to a copy where links are not supported
+	linked := false
+	if original >= 0 {
+		// os.Link refuses to replace a file that --force allows overwriting
+		if ds.force {
+			os.Remove(filePath)
+		}
+		linked = os.Link(ds.entries[original].Location, filePath) == nil
+	}
+	if !linked {
+		if err := os.WriteFile(filePath, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
 	}
 	
 	// Store the filename for tracking
-	ds.files = append(ds.files, filepath)
+	ds.files = append(ds.files, filePath)
+	ds.entries = append(ds.entries, Entry{
+		Location:    filePath,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hash,
+		Ref:         ref,
+		Stripped:    stripped,
+		fingerprint: print,
+	})
+	if ds.manifest != nil {
+		ds.manifest.Record(ref.URL, ds.manifestEntry(ds.entries[len(ds.entries)-1]))
+	}
+	
+	return filePath, nil
+}
+
+// StoreResized saves a downscaled copy of the image stored at location
+// under the ResizedDir subdirectory, keeping the original untouched. The
+// copy is derived data and is replaced on every run regardless of force.
+func (ds *DiskStorage) StoreResized(location string, resized imaging.Resized) (string, error) {
+	i := slices.IndexFunc(ds.entries, func(entry Entry) bool { return entry.Location == location })
+	if i < 0 {
+		return "", fmt.Errorf("no image stored at %s", location)
+	}
+	original, err := filepath.Rel(ds.outputDir, location)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", location, err)
+	}
+	
+	// The extension follows the new encoding, e.g. a GIF becomes PNG
+	file := filepath.Join(ResizedDir, strings.TrimSuffix(original, filepath.Ext(original))+DetermineExtension(resized.ContentType, ""))
+	filePath := filepath.Join(ds.outputDir, file)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", filePath, err)
+	}
+	if err := os.WriteFile(filePath, resized.Data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
 	
-	return filepath, nil
+	ds.entries[i].Resized = &Variant{
+		Location:    filePath,
+		ContentType: resized.ContentType,
+		Size:        int64(len(resized.Data)),
+		Width:       resized.Width,
+		Height:      resized.Height,
+	}
+	if ds.manifest != nil {
+		for url, record := range ds.manifest.Images {
+			if record.File == filepath.ToSlash(original) {
+				record.Resized = filepath.ToSlash(file)
+				ds.manifest.Record(url, record)
+			}
+		}
+	}
+	return filePath, nil
+}
+
+// StoreSheets saves contact sheets to the output directory as
+// contact-sheet-NN.png. Existing sheets are only replaced with force or in
+// sync mode, as for images, and then any left over from an earlier run
+// beyond the new count are removed too.
+func (ds *DiskStorage) StoreSheets(sheets []imaging.Sheet) ([]Variant, error) {
+	replace := ds.force || ds.manifest != nil
+	if !replace {
+		// Checked up front so a refusal leaves no partial set behind
+		for i := range sheets {
+			filePath := filepath.Join(ds.outputDir, fmt.Sprintf(sheetName, i+1))
+			if _, err := os.Stat(filePath); err == nil {
+				return nil, fmt.Errorf("file %s already exists (use --force to overwrite)", filePath)
+			}
+		}
+	}
+
+	variants := make([]Variant, 0, len(sheets))
+	for i, sheet := range sheets {
+		filePath := filepath.Join(ds.outputDir, fmt.Sprintf(sheetName, i+1))
+		if err := os.WriteFile(filePath, sheet.Data, 0644); err != nil {
+			return variants, fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+		variants = append(variants, Variant{
+			Location:    filePath,
+			ContentType: "image/png",
+			Size:        int64(len(sheet.Data)),
+			Width:       sheet.Width,
+			Height:      sheet.Height,
+		})
+	}
+	if replace {
+		for n := len(sheets) + 1; ; n++ {
+			if err := os.Remove(filepath.Join(ds.outputDir, fmt.Sprintf(sheetName, n))); err != nil {
+				break
+			}
+		}
+	}
+	return variants, nil
+}
+
+// manifestEntry builds the sync manifest record for a stored entry
+func (ds *DiskStorage) manifestEntry(entry Entry) ManifestEntry {
+	file, err := filepath.Rel(ds.outputDir, entry.Location)
+	if err != nil {
+		file = filepath.Base(entry.Location)
+	}
+	return ManifestEntry{
+		File:        filepath.ToSlash(file),
+		SHA256:      entry.SHA256,
+		ContentType: entry.ContentType,
+		Size:        entry.Size,
+		StoredAt:    time.Now().UTC(),
+	}
+}
+
+// filename picks the name, relative to the output directory, for the next
+// stored image, found at position in its thread
+func (ds *DiskStorage) filename(position int, data []byte, extension string, ref markdown.ImageRef) (string, error) {
+	if ds.names == nil {
+		index := len(ds.files)
+		if ds.manifest != nil {
+			index = ds.nextFreeIndex()
+		}
+		return GenerateFilename(index, extension), nil
+	}
+
+	name, err := ds.names.Execute(NewNameData(position, len(ds.files), data, ref), extension)
+	if err != nil {
+		return "", err
+	}
+	if err := security.ValidateOutputPath(ds.outputDir, name); err != nil {
+		return "", fmt.Errorf("invalid filename from name template: %w", err)
+	}
+
+	// Templates need not yield unique names; later images with a name
+	// already taken get a numeric suffix. Sync runs also step around
+	// files left by earlier runs rather than overwrite them.
+	stem := strings.TrimSuffix(name, extension)
+	for n := 2; ds.taken(name); n++ {
+		name = fmt.Sprintf("%s-%d%s", stem, n, extension)
+	}
+	return name, nil
+}
+
+// taken reports whether name was already written by this storage or, in
+// sync mode, exists on disk
+func (ds *DiskStorage) taken(name string) bool {
+	if ds.used[name] {
+		return true
+	}
+	return ds.manifest != nil && ds.Exists(name)
+}
+
+// nextFreeIndex returns the lowest filename index from ds.next on that no
+// file uses under any extension, so sync runs never overwrite or shadow
+// earlier images
+func (ds *DiskStorage) nextFreeIndex() int {
+	for {
+		index := ds.next
+		ds.next++
+		stem := strings.TrimSuffix(GenerateFilename(index, ".bin"), ".bin")
+		if matches, _ := filepath.Glob(filepath.Join(ds.outputDir, stem+".*")); len(matches) == 0 {
+			return index
+		}
+	}
+}
+
+// Entries returns every stored image along with its provenance
+func (ds *DiskStorage) Entries() []Entry {
+	result := make([]Entry, len(ds.entries))
+	copy(result, ds.entries)
+	return result
 }
 
 // GetFiles returns all stored file paths
@@ -120,6 +464,7 @@ func (ds *DiskStorage) Cleanup() error {
 	
 	// Clear the files list
 	ds.files = ds.files[:0]
+	ds.entries = ds.entries[:0]
 	return nil
 }
 
@@ -3,7 +3,11 @@ package storage
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/markdown"
 )
 
 func TestNewDiskStorage(t *testing.T) {
@@ -95,6 +99,36 @@ func TestDiskStorage_Store(t *testing.T) {
 	}
 }
 
+func TestDiskStorage_StoreRef(t *testing.T) {
+	tempDir := t.TempDir()
+	ds, _ := NewDiskStorage(tempDir, false)
+
+	ref := markdown.ImageRef{
+		URL:    "https://example.com/shot.png",
+		Alt:    "fixed",
+		Source: markdown.Source{Kind: markdown.SourceComment, CommentID: 12, Author: "maintainer"},
+	}
+
+	filePath, err := ds.StoreRef([]byte("test image data"), "image/png", ref)
+	if err != nil {
+		t.Fatalf("StoreRef failed: %v", err)
+	}
+
+	entries := ds.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Entries() returned %d entries, want 1", len(entries))
+	}
+	if entries[0].Location != filePath {
+		t.Errorf("Entry Location = %q, want %q", entries[0].Location, filePath)
+	}
+	if entries[0].Ref != ref {
+		t.Errorf("Entry Ref = %+v, want %+v", entries[0].Ref, ref)
+	}
+	if entries[0].Size != int64(len("test image data")) {
+		t.Errorf("Entry Size = %d, want %d", entries[0].Size, len("test image data"))
+	}
+}
+
 func TestDiskStorage_Store_EmptyData(t *testing.T) {
 	tempDir := t.TempDir()
 	ds, _ := NewDiskStorage(tempDir, false)
@@ -284,4 +318,85 @@ func TestDiskStorage_Cleanup(t *testing.T) {
 	if ds.Count() != 0 {
 		t.Errorf("Count after cleanup = %d, want 0", ds.Count())
 	}
-}
\ No newline at end of file
+}
+
+func TestDiskStorage_StoreResized(t *testing.T) {
+	dir := t.TempDir()
+	m, _ := LoadManifest(dir)
+	ds, _ := NewDiskStorage(dir, false)
+	ds.SetManifest(m)
+
+	ref := markdown.ImageRef{URL: "https://example.com/anim.gif"}
+	original, err := ds.StoreRef([]byte("original"), "image/gif", ref)
+	if err != nil {
+		t.Fatalf("StoreRef failed: %v", err)
+	}
+
+	resized, err := ds.StoreResized(original, imaging.Resized{Data: []byte("small"), ContentType: "image/png", Width: 10, Height: 5})
+	if err != nil {
+		t.Fatalf("StoreResized failed: %v", err)
+	}
+	if want := filepath.Join(dir, ResizedDir, "img-01.png"); resized != want {
+		t.Errorf("Resized copy saved as %s, want %s", resized, want)
+	}
+	if data, _ := os.ReadFile(original); string(data) != "original" {
+		t.Errorf("Original overwritten with %q", data)
+	}
+
+	variant := ds.Entries()[0].Resized
+	if variant == nil || variant.Location != resized || variant.Size != 5 || variant.Width != 10 {
+		t.Errorf("Entry variant = %+v", variant)
+	}
+	if record := m.Images[ref.URL]; record.Resized != "resized/img-01.png" {
+		t.Errorf("Manifest records resized copy as %q", record.Resized)
+	}
+
+	// Pruning the image removes its resized copy as well
+	if removed, err := m.Prune(nil); err != nil || len(removed) != 2 {
+		t.Fatalf("Prune = %v, %v, want original and copy removed", removed, err)
+	}
+	if _, err := os.Stat(resized); !os.IsNotExist(err) {
+		t.Error("Resized copy still exists after prune")
+	}
+
+	if _, err := ds.StoreResized(filepath.Join(dir, "missing.png"), imaging.Resized{}); err == nil {
+		t.Error("Expected error for an image that was never stored")
+	}
+}
+
+func TestDiskStorage_StoreSheets(t *testing.T) {
+	dir := t.TempDir()
+	ds, _ := NewDiskStorage(dir, false)
+
+	sheets := []imaging.Sheet{{Data: []byte("one"), Width: 20, Height: 10}, {Data: []byte("two")}, {Data: []byte("three")}}
+	if _, err := ds.StoreSheets(sheets); err != nil {
+		t.Fatalf("StoreSheets failed: %v", err)
+	}
+
+	// Without force, sheets from an earlier run are left alone
+	if _, err := ds.StoreSheets([]imaging.Sheet{{Data: []byte("new")}}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("StoreSheets error = %v, want refusal to overwrite", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "contact-sheet-01.png")); string(data) != "one" {
+		t.Errorf("First sheet holds %q after a refused overwrite, want the original", data)
+	}
+
+	// A forced run with fewer sheets replaces the first and drops the rest
+	forced, _ := NewDiskStorage(dir, true)
+	variants, err := forced.StoreSheets([]imaging.Sheet{{Data: []byte("new"), Width: 20, Height: 10}})
+	if err != nil {
+		t.Fatalf("StoreSheets failed: %v", err)
+	}
+	want := Variant{Location: filepath.Join(dir, "contact-sheet-01.png"), ContentType: "image/png", Size: 3, Width: 20, Height: 10}
+	if len(variants) != 1 || variants[0] != want {
+		t.Errorf("Variants = %+v, want %+v", variants, want)
+	}
+	if data, _ := os.ReadFile(want.Location); string(data) != "new" {
+		t.Errorf("First sheet holds %q, want the new sheet", data)
+	}
+	for _, stale := range []string{"contact-sheet-02.png", "contact-sheet-03.png"} {
+		if _, err := os.Stat(filepath.Join(dir, stale)); !os.IsNotExist(err) {
+			t.Errorf("Stale sheet %s was kept", stale)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+// Entry records a stored image together with where it was referenced
+type Entry struct {
+	Location    string // File path for disk storage, base64 data for memory storage
+	ContentType string
+	Size        int64
+	SHA256      string // Hex-encoded SHA-256 of the stored bytes
+	Ref         markdown.ImageRef
+
+	// Duplicates lists the other places identical content appeared when
+	// it was stored only once, see DedupeSkip
+	Duplicates []markdown.ImageRef
+	// Similar lists near-duplicates skipped in favour of this image, see
+	// SetSimilarity on the storages
+	Similar []Similar
+
+	// Resized is the downscaled copy saved next to the original in disk
+	// mode, see DiskStorage.StoreResized
+	Resized *Variant
+
+	// Stripped describes the metadata removed before storing, see
+	// SetStripMetadata on the storages
+	Stripped []string
+
+	fingerprint *imaging.Fingerprint // Only computed when similarity is enabled
+}
+
+// Variant is a processed copy of a stored image
+type Variant struct {
+	Location    string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+}
+
+// HashData returns the hex-encoded SHA-256 digest of data
+func HashData(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestFile is the name of the sync manifest kept in an output directory
+const ManifestFile = ".gh-ccimg-manifest.json"
+
+// ManifestEntry records the file an image URL was saved to
+type ManifestEntry struct {
+	File        string    `json:"file"` // Relative to the output directory
+	SHA256      string    `json:"sha256"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StoredAt    time.Time `json:"stored_at"`
+	Resized     string    `json:"resized,omitempty"` // Downscaled copy, relative to the output directory
+}
+
+// Manifest maps the source URL of every image saved to an output directory
+// to its file, so later runs only download images that are new
+type Manifest struct {
+	Images map[string]ManifestEntry `json:"images"`
+	// Frames maps the URL of an animated GIF to the URLs of the frames
+	// extracted from it, which are listed in Images
+	Frames map[string][]string `json:"frames,omitempty"`
+
+	dir string
+}
+
+// LoadManifest reads the manifest of dir. A directory without one yields
+// an empty manifest.
+func LoadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{Images: make(map[string]ManifestEntry), Frames: make(map[string][]string), dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filepath.Join(dir, ManifestFile), err)
+	}
+	if m.Images == nil {
+		m.Images = make(map[string]ManifestEntry)
+	}
+	if m.Frames == nil {
+		m.Frames = make(map[string][]string)
+	}
+	return m, nil
+}
+
+// Save writes the manifest back to its directory
+func (m *Manifest) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted run never leaves a
+	// truncated manifest behind
+	tmp, err := os.CreateTemp(m.dir, ManifestFile+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	_, werr := tmp.Write(append(data, '\n'))
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write manifest: %w", errors.Join(werr, cerr))
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(m.dir, ManifestFile)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the entry for url if its file is still on disk
+func (m *Manifest) Lookup(url string) (ManifestEntry, bool) {
+	entry, ok := m.Images[url]
+	if !ok {
+		return ManifestEntry{}, false
+	}
+	if _, err := os.Stat(m.Path(entry)); err != nil {
+		return ManifestEntry{}, false
+	}
+	return entry, true
+}
+
+// Record stores the entry for url
+func (m *Manifest) Record(url string, entry ManifestEntry) {
+	m.Images[url] = entry
+}
+
+// LookupFrames returns the frame URLs recorded for the animated GIF at url
+// if every frame's file is still on disk
+func (m *Manifest) LookupFrames(url string) ([]string, bool) {
+	frames, ok := m.Frames[url]
+	if !ok || len(frames) == 0 {
+		return nil, false
+	}
+	for _, frame := range frames {
+		if _, ok := m.Lookup(frame); !ok {
+			return nil, false
+		}
+	}
+	return frames, true
+}
+
+// RecordFrames stores the frame URLs extracted from the animated GIF at
+// url, replacing those of an earlier run
+func (m *Manifest) RecordFrames(url string, frames []string) {
+	m.Frames[url] = frames
+}
+
+// Path returns the full path of an entry's file
+func (m *Manifest) Path(entry ManifestEntry) string {
+	return filepath.Join(m.dir, entry.File)
+}
+
+// Prune deletes the files, and downscaled copies, of every image whose URL
+// is not in keep and drops them from the manifest. Frames stay as long as
+// the GIF they were extracted from is kept, even if this run could not
+// download it again. It returns the removed paths in sorted order.
+func (m *Manifest) Prune(keep map[string]bool) ([]string, error) {
+	kept := make(map[string]bool, len(keep))
+	for url, ok := range keep {
+		kept[url] = ok
+	}
+	for url, frames := range m.Frames {
+		if !keep[url] {
+			delete(m.Frames, url)
+			continue
+		}
+		for _, frame := range frames {
+			kept[frame] = true
+		}
+	}
+	keep = kept
+
+	// Duplicates share the file of the image they repeat, which must stay
+	// as long as any of their URLs is still referenced
+	inUse := make(map[string]bool)
+	for url, entry := range m.Images {
+		if keep[url] {
+			inUse[entry.File] = true
+		}
+	}
+
+	var removed []string
+	for url, entry := range m.Images {
+		if keep[url] {
+			continue
+		}
+		delete(m.Images, url)
+		if inUse[entry.File] {
+			continue
+		}
+		inUse[entry.File] = true // Remove shared files only once
+
+		files := []string{entry.File}
+		if entry.Resized != "" {
+			files = append(files, entry.Resized)
+		}
+		for _, file := range files {
+			path := filepath.Join(m.dir, file)
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+func TestLoadManifest_Missing(t *testing.T) {
+	m, err := LoadManifest(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(m.Images) != 0 {
+		t.Errorf("New manifest has %d images, want 0", len(m.Images))
+	}
+}
+
+func TestLoadManifest_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte("{"), 0644)
+	if _, err := LoadManifest(dir); err == nil {
+		t.Error("Expected error for corrupt manifest")
+	}
+}
+
+func TestDiskStorage_Sync(t *testing.T) {
+	dir := t.TempDir()
+	a := markdown.ImageRef{URL: "https://example.com/a.png"}
+	b := markdown.ImageRef{URL: "https://example.com/b.jpg"}
+	c := markdown.ImageRef{URL: "https://example.com/c.png"}
+
+	// First run saves a and b
+	m, _ := LoadManifest(dir)
+	ds, _ := NewDiskStorage(dir, false)
+	ds.SetManifest(m)
+	for _, ref := range []markdown.ImageRef{a, b} {
+		if _, err := ds.StoreRef([]byte(ref.URL), "", ref); err != nil {
+			t.Fatalf("StoreRef(%s) failed: %v", ref.URL, err)
+		}
+	}
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Second run keeps a and b and adds c after them without overwriting
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(m.Images) != 2 {
+		t.Fatalf("Reloaded manifest has %d images, want 2", len(m.Images))
+	}
+	ds, _ = NewDiskStorage(dir, false)
+	ds.SetManifest(m)
+
+	keptA, ok := ds.Keep(a)
+	if !ok || filepath.Base(keptA) != "img-01.png" {
+		t.Errorf("Keep(a) = %q, %v, want img-01.png", keptA, ok)
+	}
+	if _, ok := ds.Keep(c); ok {
+		t.Error("Keep(c) succeeded for an image never saved")
+	}
+	pathC, err := ds.StoreRef([]byte("c"), "image/png", c)
+	if err != nil {
+		t.Fatalf("StoreRef(c) failed: %v", err)
+	}
+	if filepath.Base(pathC) != "img-03.png" {
+		t.Errorf("New image saved as %s, want img-03.png", filepath.Base(pathC))
+	}
+	// An image already in the manifest is kept rather than rewritten
+	pathB, err := ds.StoreRef([]byte("changed"), "image/jpeg", b)
+	if err != nil || filepath.Base(pathB) != "img-02.jpg" {
+		t.Errorf("StoreRef(b) = %q, %v, want kept img-02.jpg", pathB, err)
+	}
+	if data, _ := os.ReadFile(pathB); string(data) != b.URL {
+		t.Errorf("img-02.jpg was overwritten with %q", data)
+	}
+
+	entries := ds.Entries()
+	if len(entries) != 3 || entries[0].Ref.URL != a.URL || entries[0].SHA256 != HashData([]byte(a.URL)) {
+		t.Errorf("Entries = %+v, want a kept with its stored hash", entries)
+	}
+
+	// Pruning drops everything not referenced any more
+	removed, err := m.Prune(map[string]bool{c.URL: true})
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("Prune = %v, %v, want 2 removed", removed, err)
+	}
+	if _, err := os.Stat(keptA); !os.IsNotExist(err) {
+		t.Error("Pruned file still exists")
+	}
+	if _, ok := m.Lookup(c.URL); !ok || len(m.Images) != 1 {
+		t.Errorf("Manifest after prune = %v, want only c", m.Images)
+	}
+}
+
+func TestManifest_LookupMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	m, _ := LoadManifest(dir)
+	m.Record("https://example.com/gone.png", ManifestEntry{File: "img-01.png"})
+
+	if _, ok := m.Lookup("https://example.com/gone.png"); ok {
+		t.Error("Lookup succeeded although the file was deleted")
+	}
+}
+
+func TestManifest_PruneSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "img-01.png"), []byte("shot"), 0644)
+	m, _ := LoadManifest(dir)
+	m.Record("https://example.com/shot.png", ManifestEntry{File: "img-01.png"})
+	m.Record("https://camo.githubusercontent.com/shot", ManifestEntry{File: "img-01.png"})
+
+	// A duplicate no longer referenced must not take the original with it
+	removed, err := m.Prune(map[string]bool{"https://example.com/shot.png": true})
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("Prune = %v, %v, want nothing removed", removed, err)
+	}
+	if _, ok := m.Lookup("https://example.com/shot.png"); !ok || len(m.Images) != 1 {
+		t.Errorf("Manifest after prune = %v, want only the original URL", m.Images)
+	}
+}
+
+func TestManifest_Frames(t *testing.T) {
+	dir := t.TempDir()
+	gif := markdown.ImageRef{URL: "https://example.com/demo.gif"}
+	frames := []string{gif.URL + "#frame=1", gif.URL + "#frame=5"}
+
+	// First run saves the frames and records the GIF they came from
+	m, _ := LoadManifest(dir)
+	ds, _ := NewDiskStorage(dir, false)
+	ds.SetManifest(m)
+	for _, url := range frames {
+		if _, err := ds.StoreRef([]byte(url), "image/png", markdown.ImageRef{URL: url}); err != nil {
+			t.Fatalf("StoreRef(%s) failed: %v", url, err)
+		}
+	}
+	m.RecordFrames(gif.URL, frames)
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Second run keeps the frames without the GIF
+	m, _ = LoadManifest(dir)
+	ds, _ = NewDiskStorage(dir, false)
+	ds.SetManifest(m)
+	if _, ok := ds.Keep(gif); ok {
+		t.Error("Keep(gif) succeeded although only its frames were saved")
+	}
+	paths, ok := ds.KeepFrames(gif)
+	if !ok || len(paths) != 2 || ds.Count() != 2 {
+		t.Fatalf("KeepFrames = %v, %v, want both frames kept", paths, ok)
+	}
+	if ds.Entries()[1].Ref.URL != frames[1] {
+		t.Errorf("Kept frame URL = %s, want %s", ds.Entries()[1].Ref.URL, frames[1])
+	}
+
+	// Frames stay while the GIF is referenced, even if it was not
+	// downloaded again, and go with it
+	removed, err := m.Prune(map[string]bool{gif.URL: true})
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("Prune = %v, %v, want frames of a referenced GIF kept", removed, err)
+	}
+	removed, err = m.Prune(map[string]bool{})
+	if err != nil || len(removed) != 2 || len(m.Frames) != 0 {
+		t.Errorf("Prune = %v, %v with frames %v, want both frames removed", removed, err, m.Frames)
+	}
+
+	// A missing frame means the GIF has to be fetched again
+	m.Record(frames[0], ManifestEntry{File: "img-01.png"})
+	m.RecordFrames(gif.URL, frames[:1])
+	if _, ok := m.LookupFrames(gif.URL); ok {
+		t.Error("LookupFrames succeeded although the frame file was deleted")
+	}
+}
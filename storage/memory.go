@@ -3,35 +3,123 @@ package storage
 import (
 	"encoding/base64"
 	"fmt"
+
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/markdown"
 )
 
 // MemoryStorage handles in-memory storage of images as base64 strings
 type MemoryStorage struct {
-	images []string
+	images  []string
+	entries []Entry
+	dedupe  DedupeMode
+	similar int
+	strip   bool
 }
 
 // NewMemoryStorage creates a new memory storage instance
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		images: make([]string, 0),
+		images:  make([]string, 0),
+		entries: make([]Entry, 0),
+		dedupe:  DedupeOff,
 	}
 }
 
+// SetDedupe sets how images identical to one already stored are handled.
+// DedupeLink behaves like DedupeSkip since there are no files to link.
+func (ms *MemoryStorage) SetDedupe(mode DedupeMode) {
+	ms.dedupe = mode
+}
+
+// SetSimilarity skips images whose perceptual fingerprint is within
+// threshold bits of one already stored, recording them on that entry
+// instead. 0 disables near-duplicate detection.
+func (ms *MemoryStorage) SetSimilarity(threshold int) {
+	ms.similar = threshold
+}
+
+// SetStripMetadata removes EXIF, XMP and similar metadata from JPEG and
+// PNG images before they are encoded
+func (ms *MemoryStorage) SetStripMetadata(strip bool) {
+	ms.strip = strip
+}
+
 // Store stores image data in memory as base64 and returns the encoded string
 func (ms *MemoryStorage) Store(data []byte, contentType, url string) (string, error) {
+	return ms.StoreRef(data, contentType, markdown.ImageRef{URL: url})
+}
+
+// StoreRef stores image data in memory as base64, recording where the image
+// was referenced, and returns the encoded string
+func (ms *MemoryStorage) StoreRef(data []byte, contentType string, ref markdown.ImageRef) (string, error) {
 	if len(data) == 0 {
 		return "", fmt.Errorf("cannot store empty data")
 	}
 	
+	// Metadata goes first so copies differing only in EXIF are identical
+	var stripped []string
+	if ms.strip {
+		data, stripped = stripMetadata(data)
+	}
+	
+	// Identical content is only encoded once unless deduplication is off
+	hash := HashData(data)
+	if ms.dedupe != DedupeOff {
+		if i := findHash(ms.entries, hash); i >= 0 {
+			addDuplicate(ms.entries, i, ref)
+			return ms.entries[i].Location, nil
+		}
+	}
+	var print *imaging.Fingerprint
+	if ms.similar > 0 {
+		print = fingerprint(data)
+		if i, distance := findSimilar(ms.entries, print, ms.similar); i >= 0 {
+			addSimilar(ms.entries, i, ref, distance)
+			return ms.entries[i].Location, nil
+		}
+	}
+	
 	// Create base64 encoded string
 	encoded := base64.StdEncoding.EncodeToString(data)
 	
 	// Store in memory
 	ms.images = append(ms.images, encoded)
+	ms.entries = append(ms.entries, Entry{
+		Location:    encoded,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hash,
+		Ref:         ref,
+		Stripped:    stripped,
+		fingerprint: print,
+	})
 	
 	return encoded, nil
 }
 
+// StoreSheets encodes contact sheets as base64 like stored images
+func (ms *MemoryStorage) StoreSheets(sheets []imaging.Sheet) []Variant {
+	variants := make([]Variant, 0, len(sheets))
+	for _, sheet := range sheets {
+		variants = append(variants, Variant{
+			Location:    base64.StdEncoding.EncodeToString(sheet.Data),
+			ContentType: "image/png",
+			Size:        int64(len(sheet.Data)),
+			Width:       sheet.Width,
+			Height:      sheet.Height,
+		})
+	}
+	return variants
+}
+
+// Entries returns every stored image along with its provenance
+func (ms *MemoryStorage) Entries() []Entry {
+	result := make([]Entry, len(ms.entries))
+	copy(result, ms.entries)
+	return result
+}
+
 // GetImages returns all stored base64 encoded images
 func (ms *MemoryStorage) GetImages() []string {
 	// Return a copy to prevent external modification
@@ -48,6 +136,7 @@ func (ms *MemoryStorage) Count() int {
 // Clear removes all stored images
 func (ms *MemoryStorage) Clear() {
 	ms.images = ms.images[:0]
+	ms.entries = ms.entries[:0]
 }
 
 // GetImageData returns the raw image data for a given base64 string
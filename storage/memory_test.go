@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/base64"
 	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/markdown"
 )
 
 func TestNewMemoryStorage(t *testing.T) {
@@ -45,6 +48,33 @@ func TestMemoryStorage_Store(t *testing.T) {
 	}
 }
 
+func TestMemoryStorage_StoreRef(t *testing.T) {
+	ms := NewMemoryStorage()
+	ref := markdown.ImageRef{
+		URL:    "https://example.com/expected.png",
+		Source: markdown.Source{Kind: markdown.SourceIssueBody, Author: "reporter"},
+	}
+
+	encoded, err := ms.StoreRef([]byte("test image data"), "image/png", ref)
+	if err != nil {
+		t.Fatalf("StoreRef failed: %v", err)
+	}
+
+	entries := ms.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Entries() returned %d entries, want 1", len(entries))
+	}
+	if entries[0].Location != encoded {
+		t.Errorf("Entry Location = %q, want %q", entries[0].Location, encoded)
+	}
+	if entries[0].Ref != ref {
+		t.Errorf("Entry Ref = %+v, want %+v", entries[0].Ref, ref)
+	}
+	if entries[0].ContentType != "image/png" {
+		t.Errorf("Entry ContentType = %q, want %q", entries[0].ContentType, "image/png")
+	}
+}
+
 func TestMemoryStorage_Store_EmptyData(t *testing.T) {
 	ms := NewMemoryStorage()
 	
@@ -169,4 +199,16 @@ func TestMemoryStorage_EstimateMemoryUsage(t *testing.T) {
 	if usage < expectedSize-5 || usage > expectedSize+5 {
 		t.Errorf("Memory usage %d not close to expected %d", usage, expectedSize)
 	}
-}
\ No newline at end of file
+}
+func TestMemoryStorage_StoreSheets(t *testing.T) {
+	ms := NewMemoryStorage()
+	variants := ms.StoreSheets([]imaging.Sheet{{Data: []byte("sheet"), Width: 40, Height: 30}})
+
+	want := Variant{Location: "c2hlZXQ=", ContentType: "image/png", Size: 5, Width: 40, Height: 30}
+	if len(variants) != 1 || variants[0] != want {
+		t.Errorf("Variants = %+v, want %+v", variants, want)
+	}
+	if ms.Count() != 0 {
+		t.Error("Contact sheets were counted as stored images")
+	}
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "github.com/kojikawamura/gh-ccimg/imaging"
+
+// stripMetadata removes metadata from data before it is stored. Images
+// that cannot be parsed are stored unchanged rather than rejected.
+func stripMetadata(data []byte) ([]byte, []string) {
+	stripped, removed, err := imaging.StripMetadata(data)
+	if err != nil {
+		return data, nil
+	}
+	return stripped, removed
+}
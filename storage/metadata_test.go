@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+// taggedJPEG returns a small JPEG carrying an EXIF block whose camera make
+// is device
+func taggedJPEG(t *testing.T, device string) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	plain := buf.Bytes()
+
+	// One ASCII entry for the make, stored after the directory
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x0f\x00\x02")
+	tiff = binary.BigEndian.AppendUint32(tiff, uint32(len(device)+1))
+	tiff = binary.BigEndian.AppendUint32(tiff, 8+2+12+4)
+	tiff = binary.BigEndian.AppendUint32(tiff, 0)
+	tiff = append(tiff, device+"\x00"...)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	data := append([]byte{}, plain[:2]...)
+	data = append(data, 0xff, 0xe1)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(payload)+2))
+	data = append(data, payload...)
+	return append(data, plain[2:]...)
+}
+
+func TestDiskStorage_StripMetadata(t *testing.T) {
+	refs := []markdown.ImageRef{{URL: "https://example.com/a.jpg"}, {URL: "https://example.com/b.jpg"}}
+	data := [][]byte{taggedJPEG(t, "Apple"), taggedJPEG(t, "Google")}
+
+	ds, _ := NewDiskStorage(t.TempDir(), false)
+	ds.SetDedupe(DedupeSkip)
+	ds.SetStripMetadata(true)
+	for i, ref := range refs {
+		if _, err := ds.StoreRef(data[i], "image/jpeg", ref); err != nil {
+			t.Fatalf("StoreRef(%s) failed: %v", ref.URL, err)
+		}
+	}
+
+	// Without their EXIF blocks the two photos are identical
+	entries := ds.Entries()
+	if len(entries) != 1 || len(entries[0].Duplicates) != 1 {
+		t.Fatalf("Got %d entries, want one with a duplicate", len(entries))
+	}
+	if got := entries[0].Stripped; len(got) != 1 || got[0] != "EXIF (device Apple)" {
+		t.Errorf("Stripped = %q, want the EXIF block", got)
+	}
+	saved, err := os.ReadFile(entries[0].Location)
+	if err != nil {
+		t.Fatalf("Failed to read stored file: %v", err)
+	}
+	if bytes.Contains(saved, []byte("Apple")) || int64(len(saved)) != entries[0].Size {
+		t.Errorf("Stored file still carries metadata or has the wrong size")
+	}
+}
+
+func TestMemoryStorage_StripMetadata(t *testing.T) {
+	data := taggedJPEG(t, "Apple")
+
+	for _, strip := range []bool{false, true} {
+		ms := NewMemoryStorage()
+		ms.SetStripMetadata(strip)
+		if _, err := ms.Store(data, "image/jpeg", "https://example.com/a.jpg"); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+		entry := ms.Entries()[0]
+		if kept := entry.Size == int64(len(data)); kept == strip {
+			t.Errorf("strip %v: stored %d of %d bytes", strip, entry.Size, len(data))
+		}
+		if (len(entry.Stripped) > 0) != strip {
+			t.Errorf("strip %v: Stripped = %q", strip, entry.Stripped)
+		}
+	}
+}
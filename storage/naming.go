@@ -44,6 +44,9 @@ func ExtractExtensionFromURL(url string) string {
 	
 	// Convert to lowercase and validate it's a reasonable image extension
 	ext = strings.ToLower(ext)
+	if ext == ".tif" {
+		ext = ".tiff"
+	}
 	validExtensions := []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".bmp", ".tiff", ".ico"}
 	
 	for _, valid := range validExtensions {
@@ -58,6 +61,8 @@ func ExtractExtensionFromURL(url string) string {
 
 // DetermineExtension determines the best extension to use
 // Priority: contentType > URL > default
+// The content type wins so images converted with --convert-to are named
+// after their new format rather than the one in the URL
 func DetermineExtension(contentType, url string) string {
 	// First try to get extension from content type
 	if contentType != "" {
@@ -40,6 +40,7 @@ func TestExtractExtensionFromURL(t *testing.T) {
 		{"GIF URL", "https://example.com/animation.gif", ".gif"},
 		{"WebP URL", "https://example.com/modern.webp", ".webp"},
 		{"SVG URL", "https://example.com/vector.svg", ".svg"},
+		{"short TIFF URL", "https://example.com/scan.tif", ".tiff"},
 		{"uppercase extension", "https://example.com/IMAGE.PNG", ".png"},
 		{"with query params", "https://example.com/image.png?size=large", ".png"},
 		{"with fragment", "https://example.com/image.jpg#section", ".jpg"},
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"github.com/kojikawamura/gh-ccimg/imaging"
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+// Similar is a near-duplicate that was skipped in favour of an image that
+// looks the same, such as a re-taken screenshot with a slightly different
+// crop or compression
+type Similar struct {
+	Ref      markdown.ImageRef
+	Distance int // Perceptual hash distance in bits, see imaging.Fingerprint
+}
+
+// fingerprint returns the perceptual fingerprint of data, or nil for
+// formats that cannot be decoded such as SVG
+func fingerprint(data []byte) *imaging.Fingerprint {
+	print, err := imaging.FingerprintData(data)
+	if err != nil {
+		return nil
+	}
+	return &print
+}
+
+// findSimilar returns the index of the closest entry whose fingerprint is
+// within threshold bits of print along with its distance, or -1
+func findSimilar(entries []Entry, print *imaging.Fingerprint, threshold int) (int, int) {
+	best, bestDistance := -1, threshold+1
+	if print == nil {
+		return best, 0
+	}
+	for i, entry := range entries {
+		if entry.fingerprint == nil {
+			continue
+		}
+		if distance := print.Distance(*entry.fingerprint); distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best, bestDistance
+}
+
+// addSimilar records ref as a near-duplicate of entries[i]
+func addSimilar(entries []Entry, i int, ref markdown.ImageRef, distance int) {
+	entries[i].Similar = append(entries[i].Similar, Similar{Ref: ref, Distance: distance})
+}
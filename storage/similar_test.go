@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+// checkerboard encodes a w x h board of 8x8 squares, inverted when invert
+// is set, as PNG or, with quality > 0, as JPEG
+func checkerboard(t *testing.T, w, h int, invert bool, quality int) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dark := (x*8/w+y*8/h)%2 == 0
+			if dark != invert {
+				img.SetGray(x, y, color.Gray{Y: 20})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 230})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if quality > 0 {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatalf("Failed to encode checkerboard: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStorage_Similarity(t *testing.T) {
+	images := [][]byte{
+		checkerboard(t, 320, 240, false, 0),
+		checkerboard(t, 640, 480, false, 50), // Same board, re-taken and compressed
+		checkerboard(t, 320, 240, true, 0),   // Inverted board
+		[]byte("<svg xmlns='http://www.w3.org/2000/svg'/>"),
+	}
+	refs := make([]markdown.ImageRef, len(images))
+	for i := range refs {
+		refs[i] = markdown.ImageRef{URL: "https://example.com/" + string(rune('a'+i)), Source: markdown.Source{CommentID: i}}
+	}
+
+	ds, _ := NewDiskStorage(t.TempDir(), false)
+	ds.SetSimilarity(6)
+	ms := NewMemoryStorage()
+	ms.SetSimilarity(6)
+
+	for name, store := range map[string]interface {
+		StoreRef([]byte, string, markdown.ImageRef) (string, error)
+		Entries() []Entry
+	}{"disk": ds, "memory": ms} {
+		for i, data := range images {
+			if _, err := store.StoreRef(data, "image/png", refs[i]); err != nil {
+				t.Fatalf("%s: StoreRef(%d) failed: %v", name, i, err)
+			}
+		}
+
+		entries := store.Entries()
+		if len(entries) != 3 {
+			t.Fatalf("%s: stored %d images, want 3", name, len(entries))
+		}
+		similar := entries[0].Similar
+		if len(similar) != 1 || similar[0].Ref.URL != refs[1].URL || similar[0].Distance > 6 {
+			t.Errorf("%s: Similar = %+v, want the re-taken board", name, similar)
+		}
+		if len(entries[1].Similar) != 0 || len(entries[2].Similar) != 0 {
+			t.Errorf("%s: unexpected near-duplicates of later images", name)
+		}
+	}
+}
+
+func TestStorage_SimilarityDisabled(t *testing.T) {
+	ms := NewMemoryStorage()
+	ms.StoreRef(checkerboard(t, 320, 240, false, 0), "image/png", markdown.ImageRef{URL: "https://example.com/a.png"})
+	ms.StoreRef(checkerboard(t, 640, 480, false, 50), "image/jpeg", markdown.ImageRef{URL: "https://example.com/b.jpg"})
+
+	if ms.Count() != 2 {
+		t.Errorf("Stored %d images without --dedupe-similar, want 2", ms.Count())
+	}
+}
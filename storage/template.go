@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+	"github.com/kojikawamura/gh-ccimg/security"
+)
+
+// maxSlugLength caps the length of alt text and basenames in filenames
+const maxSlugLength = 60
+
+// NameData is what a filename template is executed with
+type NameData struct {
+	Index     int    // 1-based position of the image in the thread
+	Count     int    // 1-based count of images stored so far in this run
+	Kind      string // Source kind, such as issue_body or comment
+	CommentID int    // 0 for the issue, PR or discussion body
+	Author    string // Login of the author, empty for deleted users
+	Date      string // Creation date of the source, as YYYY-MM-DD
+	Alt       string // Alt text reduced to letters, digits, '.', '_' and '-'
+	Hash      string // First 12 hex digits of the image's SHA-256
+	Basename  string // Last segment of the image URL, without extension
+}
+
+// NewNameData collects the template data for an image. position is its
+// 0-based position among the images extracted from the thread, which does
+// not change when other images fail or are skipped as duplicates; frames
+// of an animated GIF share the GIF's position. stored is the number of
+// images stored before it in this run.
+func NewNameData(position, stored int, data []byte, ref markdown.ImageRef) NameData {
+	name := NameData{
+		Index:     position + 1,
+		Count:     stored + 1,
+		Kind:      string(ref.Source.Kind),
+		CommentID: ref.Source.CommentID,
+		Author:    ref.Source.Author,
+		Alt:       slug(ref.Alt),
+		Hash:      HashData(data)[:12],
+		Basename:  slug(urlBasename(ref.URL)),
+	}
+	if !ref.Source.CreatedAt.IsZero() {
+		name.Date = ref.Source.CreatedAt.UTC().Format("2006-01-02")
+	}
+	return name
+}
+
+// NameTemplate renders image filenames from a text/template, such as
+// "{{.Author}}/{{.Date}}-{{.Hash}}". Slashes separate subdirectories; the
+// file extension is always appended.
+type NameTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseNameTemplate parses a filename template
+func ParseNameTemplate(text string) (*NameTemplate, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("name template cannot be empty")
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %w", err)
+	}
+
+	// Catch references to unknown fields before any image is downloaded
+	if _, err := (&NameTemplate{tmpl: tmpl}).render(NameData{}); err != nil {
+		return nil, err
+	}
+	return &NameTemplate{tmpl: tmpl}, nil
+}
+
+// Execute renders the relative path for an image, sanitizing every path
+// segment and appending extension
+func (t *NameTemplate) Execute(data NameData, extension string) (string, error) {
+	rendered, err := t.render(data)
+	if err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(rendered), "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		segments = append(segments, security.SanitizeFilename(segment))
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("name template produced an empty filename for image %d", data.Index)
+	}
+
+	return filepath.Join(segments...) + extension, nil
+}
+
+// render executes the template without any post-processing
+func (t *NameTemplate) render(data NameData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid name template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// urlBasename returns the last path segment of rawURL without extension.
+// Inline data: URIs have no basename.
+func urlBasename(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "data" {
+		return ""
+	}
+	base := path.Base(u.Path)
+	if base == "/" || base == "." {
+		return ""
+	}
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// slug reduces s to letters, digits, '.', '_' and '-', turning runs of
+// anything else into a single '-'
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_'):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	result := strings.Trim(b.String(), "-.")
+	if len(result) > maxSlugLength {
+		result = strings.TrimRight(result[:maxSlugLength], "-.")
+	}
+	return result
+}
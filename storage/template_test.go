@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kojikawamura/gh-ccimg/markdown"
+)
+
+func TestNameTemplate_Execute(t *testing.T) {
+	ref := markdown.ImageRef{
+		URL: "https://github.com/user-attachments/assets/Login Screen.png?raw=true",
+		Alt: "Login page: broken (Safari 17)",
+		Source: markdown.Source{
+			Kind:      markdown.SourceComment,
+			CommentID: 4242,
+			Author:    "octocat",
+			CreatedAt: time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("JST", 9*3600)),
+		},
+	}
+	data := NewNameData(4, 2, []byte("png"), ref)
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{{.Author}}/{{.Index}}", filepath.Join("octocat", "5") + ".png"},
+		{"{{.Index}}-{{.Count}}", "5-3.png"},
+		{`{{printf "%03d" .Index}}-{{.Alt}}`, "005-Login-page-broken-Safari-17.png"},
+		{"{{.Date}}_{{.CommentID}}_{{.Kind}}", "2024-03-01_4242_comment.png"},
+		{"{{.Basename}}-{{.Hash}}", "Login-Screen-" + HashData([]byte("png"))[:12] + ".png"},
+		{"../{{.Author}}/./x:y", filepath.Join("octocat", "x_y") + ".png"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := ParseNameTemplate(tt.template)
+		if err != nil {
+			t.Fatalf("ParseNameTemplate(%q) failed: %v", tt.template, err)
+		}
+		got, err := tmpl.Execute(data, ".png")
+		if err != nil {
+			t.Fatalf("Execute(%q) failed: %v", tt.template, err)
+		}
+		if got != tt.want {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseNameTemplate_Errors(t *testing.T) {
+	for _, text := range []string{"", "{{.Index", "{{.Nope}}"} {
+		if _, err := ParseNameTemplate(text); err == nil {
+			t.Errorf("ParseNameTemplate(%q) succeeded, want error", text)
+		}
+	}
+
+	tmpl, _ := ParseNameTemplate("{{.Alt}}")
+	if _, err := tmpl.Execute(NameData{Index: 1}, ".png"); err == nil {
+		t.Error("Expected error for a template rendering an empty name")
+	}
+}
+
+func TestDiskStorage_NameTemplate(t *testing.T) {
+	dir := t.TempDir()
+	ds, _ := NewDiskStorage(dir, false)
+	tmpl, _ := ParseNameTemplate("{{.Author}}/shot")
+	ds.SetNameTemplate(tmpl)
+
+	ref := markdown.ImageRef{URL: "https://example.com/a.png", Source: markdown.Source{Author: "alice"}}
+	first, err := ds.StoreRef([]byte("one"), "image/png", ref)
+	if err != nil {
+		t.Fatalf("StoreRef failed: %v", err)
+	}
+	second, err := ds.StoreRef([]byte("two"), "image/png", ref)
+	if err != nil {
+		t.Fatalf("StoreRef failed: %v", err)
+	}
+
+	if first != filepath.Join(dir, "alice", "shot.png") || second != filepath.Join(dir, "alice", "shot-2.png") {
+		t.Errorf("Stored %s and %s, want alice/shot.png and alice/shot-2.png", first, second)
+	}
+	if data, _ := os.ReadFile(first); string(data) != "one" {
+		t.Errorf("First image overwritten with %q", data)
+	}
+}
+
+func TestDiskStorage_NameTemplateIndex(t *testing.T) {
+	dir := t.TempDir()
+	ds, _ := NewDiskStorage(dir, false)
+	tmpl, _ := ParseNameTemplate("{{.Index}}-{{.Count}}")
+	ds.SetNameTemplate(tmpl)
+
+	// The image at position 1 failed to download, so the one at position 2
+	// is the second stored but keeps its place in the thread
+	for _, tt := range []struct {
+		position int
+		want     string
+	}{{0, "1-1.png"}, {2, "3-2.png"}} {
+		ref := markdown.ImageRef{URL: fmt.Sprintf("https://example.com/%d.png", tt.position)}
+		path, err := ds.StoreRefAt(tt.position, []byte(ref.URL), "image/png", ref)
+		if err != nil {
+			t.Fatalf("StoreRefAt(%d) failed: %v", tt.position, err)
+		}
+		if filepath.Base(path) != tt.want {
+			t.Errorf("StoreRefAt(%d) saved %s, want %s", tt.position, filepath.Base(path), tt.want)
+		}
+	}
+}
@@ -463,7 +463,7 @@ The page shows an error. See screenshot below:
 
 ![Network Tab](https://user-images.githubusercontent.com/12345/network.png)
 `,
-			expectedURLs: 4, // Updated based on actual extraction (includes duplicates)
+			expectedURLs: 3,
 		},
 		"feature_request_with_mockups": {
 			content: `